@@ -0,0 +1,45 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/SHA65536/TimezoneBot/discord"
+	"github.com/urfave/cli/v2"
+)
+
+// commandsCommand manages the bot's slash commands without starting it
+func commandsCommand() *cli.Command {
+	flags := func() []cli.Flag {
+		return []cli.Flag{
+			tokenFlag(),
+			&cli.StringFlag{
+				Name:    "guild",
+				Usage:   "Guild whose commands to manage instead of the global ones",
+				EnvVars: []string{"DEV_GUILD"},
+			},
+		}
+	}
+
+	return &cli.Command{
+		Name:  "commands",
+		Usage: "Manage the bot's slash commands",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "sync",
+				Usage: "Register the bot's slash commands, if they changed",
+				Flags: flags(),
+				Action: func(c *cli.Context) error {
+					return discord.SyncCommands(c.Context, c.String("dc-token"), c.String("guild"), slog.Default())
+				},
+			},
+			{
+				Name:  "purge",
+				Usage: "Remove every slash command of the bot",
+				Flags: flags(),
+				Action: func(c *cli.Context) error {
+					return discord.PurgeCommands(c.Context, c.String("dc-token"), c.String("guild"), slog.Default())
+				},
+			},
+		},
+	}
+}
@@ -2,13 +2,11 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
 
+	"github.com/SHA65536/TimezoneBot/cmd/internal/dbflags"
 	"github.com/SHA65536/TimezoneBot/database"
-	"github.com/SHA65536/TimezoneBot/discord"
 
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/urfave/cli/v2"
@@ -20,75 +18,82 @@ func main() {
 		Usage: "A custom made discord bot.",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "db-host",
-				Usage:    "Database host",
-				EnvVars:  []string{"DB_HOST"},
-				Required: true,
+				Name:    "log-level",
+				Usage:   "Minimum level of logged messages (debug, info, warn, error)",
+				EnvVars: []string{"LOG_LEVEL"},
+				Value:   "info",
 			},
 			&cli.StringFlag{
-				Name:     "db-port",
-				Usage:    "Database port",
-				EnvVars:  []string{"DB_PORT"},
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:     "db-user",
-				Usage:    "Database user",
-				EnvVars:  []string{"DB_USER"},
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:     "db-pass",
-				Usage:    "Database password",
-				EnvVars:  []string{"DB_PASS"},
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:     "db-name",
-				Usage:    "Database name",
-				EnvVars:  []string{"DB_NAME"},
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:     "dc-token",
-				Usage:    "Database name",
-				EnvVars:  []string{"DC_TOKEN"},
-				Required: true,
+				Name:    "log-format",
+				Usage:   "Format of logged messages (text, json)",
+				EnvVars: []string{"LOG_FORMAT"},
+				Value:   "text",
 			},
 		},
-		Action: func(c *cli.Context) error {
-			var db_cfg = database.DatabaseConfig{
-				Host: c.String("db-host"),
-				Port: c.String("db-port"),
-				User: c.String("db-user"),
-				Pass: c.String("db-pass"),
-				Name: c.String("db-name"),
-			}
-
-			db, err := database.MakeDatabase(db_cfg)
+		Before: func(c *cli.Context) error {
+			logger, err := newLogger(c.String("log-level"), c.String("log-format"))
 			if err != nil {
-				return fmt.Errorf("error creating db: %w", err)
-			}
-
-			srv, err := discord.MakeDiscordServer(c.String("dc-token"), db)
-			if err != nil {
-				return fmt.Errorf("error creating srv: %w", err)
+				return err
 			}
+			slog.SetDefault(logger)
+			return nil
+		},
+		// Running without a command keeps the bot's container working as it always has
+		DefaultCommand: "run",
+		Commands: []*cli.Command{
+			runCommand(),
+			usersCommand(),
+			statsCommand(),
+			commandsCommand(),
+			exportCommand(),
+			importCommand(),
+		},
+	}
 
-			if err := srv.Start(); err != nil {
-				return fmt.Errorf("error starting srv: %w", err)
-			}
+	if err := app.Run(os.Args); err != nil {
+		slog.Error("command failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
 
-			stop := make(chan os.Signal, 1)
-			signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-			<-stop
+// tokenFlag is the bot token, needed by every command talking to Discord
+func tokenFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:     "dc-token",
+		Usage:    "Discord bot token",
+		EnvVars:  []string{"DC_TOKEN"},
+		Required: true,
+	}
+}
 
-			return srv.Stop()
-		},
+// openStore connects to the database picked by the database flags
+func openStore(c *cli.Context) (database.Store, error) {
+	cfg, err := dbflags.Config(c)
+	if err != nil {
+		return nil, err
 	}
 
-	err := app.Run(os.Args)
+	store, err := database.MakeDatabase(cfg)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("error creating db: %w", err)
+	}
+	return store, nil
+}
+
+// newLogger creates a logger writing to stderr at the given level and format
+func newLogger(level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level: %s", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", format)
 	}
 }
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/cmd/internal/dbflags"
+	"github.com/SHA65536/TimezoneBot/cooldown"
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/SHA65536/TimezoneBot/discord"
+	"github.com/SHA65536/TimezoneBot/health"
+	"github.com/SHA65536/TimezoneBot/metrics"
+	"github.com/SHA65536/TimezoneBot/tracing"
+	"github.com/urfave/cli/v2"
+)
+
+// server is how the bot receives Discord events, over the gateway or HTTP interactions
+type server interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// healthCheckTimeout bounds how long a probe waits for the database and gateway checks
+const healthCheckTimeout = 2 * time.Second
+
+// runCommand runs the bot until it is interrupted
+func runCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "run",
+		Usage: "Run the bot (default)",
+		Flags: append(dbflags.Flags(),
+
+			tokenFlag(),
+			&cli.StringFlag{
+				Name:    "mode",
+				Usage:   "How Discord events are received: gateway, or http for interactions only (no reactions or message conversions)",
+				EnvVars: []string{"MODE"},
+				Value:   "gateway",
+			},
+			&cli.IntFlag{
+				Name:    "shard-count",
+				Usage:   "Total number of gateway shards, 0 uses the count recommended by Discord",
+				EnvVars: []string{"SHARD_COUNT"},
+			},
+			&cli.StringFlag{
+				Name:    "shard-ids",
+				Usage:   "Shards connected by this process, e.g. 0-3,8 (all of them when empty, requires --shard-count)",
+				EnvVars: []string{"SHARD_IDS"},
+			},
+			&cli.StringFlag{
+				Name:    "dev-guild",
+				Usage:   "Register slash commands to this guild only, where they show up instantly (for development)",
+				EnvVars: []string{"DEV_GUILD"},
+			},
+			&cli.StringFlag{
+				Name:    "public-key",
+				Usage:   "Application public key used to verify HTTP interactions, required in http mode",
+				EnvVars: []string{"DC_PUBLIC_KEY"},
+			},
+			&cli.StringFlag{
+				Name:    "interactions-addr",
+				Usage:   "Address to serve HTTP interactions on, under /interactions",
+				EnvVars: []string{"INTERACTIONS_ADDR"},
+				Value:   ":8000",
+			},
+			&cli.StringFlag{
+				Name:    "cooldown-store",
+				Usage:   "Where to keep conversion cooldowns (memory, database)",
+				EnvVars: []string{"COOLDOWN_STORE"},
+				Value:   "memory",
+			},
+			&cli.IntFlag{
+				Name:    "cooldown-capacity",
+				Usage:   "Maximum number of cooldowns kept by the memory store",
+				EnvVars: []string{"COOLDOWN_CAPACITY"},
+				Value:   10000,
+			},
+			&cli.DurationFlag{
+				Name:    "cache-ttl",
+				Usage:   "How long timezone lookups are cached",
+				EnvVars: []string{"CACHE_TTL"},
+				Value:   5 * time.Minute,
+			},
+			&cli.IntFlag{
+				Name:    "cache-size",
+				Usage:   "Maximum number of cached timezone lookups",
+				EnvVars: []string{"CACHE_SIZE"},
+				Value:   10000,
+			},
+			&cli.DurationFlag{
+				Name:    "shutdown-timeout",
+				Usage:   "How long to wait for in-flight events when shutting down",
+				EnvVars: []string{"SHUTDOWN_TIMEOUT"},
+				Value:   10 * time.Second,
+			},
+			&cli.StringFlag{
+				Name:    "metrics-addr",
+				Usage:   "Address to serve Prometheus metrics on, e.g. :9090 (disabled when empty)",
+				EnvVars: []string{"METRICS_ADDR"},
+			},
+			&cli.StringFlag{
+				Name:    "health-addr",
+				Usage:   "Address to serve /healthz and /readyz on, e.g. :8080 (disabled when empty)",
+				EnvVars: []string{"HEALTH_ADDR"},
+			},
+		),
+		Action: runBot,
+	}
+}
+
+func runBot(c *cli.Context) error {
+	logger := slog.Default()
+
+	// Traces are only exported when the standard OTEL_* variables ask for it
+	shutdownTracing, err := tracing.Setup(c.Context)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.Duration("shutdown-timeout"))
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("failed to flush traces", slog.Any("error", err))
+		}
+	}()
+
+	db_cfg, err := dbflags.Config(c)
+	if err != nil {
+		return err
+	}
+
+	// SQLite deployments have no separate migrations container, keep the schema up to date here
+	if db_cfg.Driver == database.DriverSQLite {
+		if err := database.RunMigrations(db_cfg); err != nil {
+			return fmt.Errorf("error migrating db: %w", err)
+		}
+	}
+
+	store, err := database.MakeDatabase(db_cfg)
+	if err != nil {
+		return fmt.Errorf("error creating db: %w", err)
+	}
+	defer store.Close()
+
+	// Cache hits never reach the database, so only queries that do are timed
+	m := metrics.New()
+	observed := database.NewObservedStore(store, m.ObserveQuery)
+	db := database.NewCachedStore(observed, c.Duration("cache-ttl"), c.Int("cache-size"))
+
+	var cooldowns cooldown.Store
+	switch c.String("cooldown-store") {
+	case "memory":
+		cooldowns = cooldown.NewMemory(c.Int("cooldown-capacity"))
+	case "database":
+		cooldowns = cooldown.NewDatabase(db)
+	default:
+		return fmt.Errorf("unknown cooldown store: %s", c.String("cooldown-store"))
+	}
+
+	// Metrics and probes can share a listener, and are served before connecting to Discord
+	// so orchestrators can tell a bot that is starting from one that is stuck
+	muxes := map[string]*http.ServeMux{}
+	muxFor := func(addr string) *http.ServeMux {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
+		}
+		return muxes[addr]
+	}
+	checks := health.New(healthCheckTimeout)
+	checks.Ready("database", store.Ping)
+
+	var srv server
+	switch c.String("mode") {
+	case "gateway":
+		shardIDs, err := discord.ParseShardIDs(c.String("shard-ids"))
+		if err != nil {
+			return err
+		}
+		sharding := discord.Sharding{Count: c.Int("shard-count"), IDs: shardIDs}
+		gatewaySrv, err := discord.MakeDiscordServer(c.String("dc-token"), sharding, c.String("dev-guild"), db, cooldowns, logger, m)
+		if err != nil {
+			return fmt.Errorf("error creating srv: %w", err)
+		}
+		checks.Live("heartbeat", gatewaySrv.CheckHeartbeat)
+		checks.Ready("gateway", gatewaySrv.CheckGateway)
+		srv = gatewaySrv
+	case "http":
+		if c.String("public-key") == "" {
+			return errors.New("--public-key is required in http mode")
+		}
+		interactionSrv, err := discord.MakeInteractionServer(c.String("dc-token"), c.String("public-key"), c.String("dev-guild"), db, cooldowns, logger, m)
+		if err != nil {
+			return fmt.Errorf("error creating srv: %w", err)
+		}
+		muxFor(c.String("interactions-addr")).Handle("/interactions", interactionSrv)
+		srv = interactionSrv
+	default:
+		return fmt.Errorf("unknown mode: %s", c.String("mode"))
+	}
+
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if addr := c.String("metrics-addr"); addr != "" {
+		muxFor(addr).Handle("/metrics", m.Handler())
+	}
+	if addr := c.String("health-addr"); addr != "" {
+		checks.Register(muxFor(addr))
+	}
+	httpErrs := make(chan error, len(muxes))
+	for addr, mux := range muxes {
+		httpSrv, err := serveHTTP(addr, mux, logger, httpErrs)
+		if err != nil {
+			return fmt.Errorf("error serving HTTP: %w", err)
+		}
+		defer httpSrv.Close()
+	}
+
+	if err := srv.Start(ctx); err != nil {
+		return fmt.Errorf("error starting srv: %w", err)
+	}
+
+	// A dead HTTP server takes the interactions endpoint and /healthz with it,
+	// so it stops the bot instead of leaving it up and doing nothing
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case err := <-httpErrs:
+		runErr = fmt.Errorf("error serving HTTP: %w", err)
+	}
+	stop()
+	logger.Info("shutting down")
+
+	// The store is closed by the deferred Close once every handler is done with it
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.Duration("shutdown-timeout"))
+	defer cancel()
+	if err := srv.Stop(shutdownCtx); err != nil {
+		return fmt.Errorf("error stopping srv: %w", err)
+	}
+
+	stats := db.Stats()
+	logger.Info("timezone cache stats", slog.Uint64("hits", stats.Hits), slog.Uint64("misses", stats.Misses))
+	return runErr
+}
+
+// serveHTTP serves mux on addr until the returned server is closed. Binding
+// errors are returned right away, later ones are sent on errs.
+func serveHTTP(addr string, mux *http.ServeMux, logger *slog.Logger, errs chan<- error) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("HTTP server stopped", slog.String("addr", addr), slog.Any("error", err))
+			errs <- fmt.Errorf("%s: %w", addr, err)
+		}
+	}()
+	logger.Info("serving HTTP", slog.String("addr", addr))
+	return srv, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/SHA65536/TimezoneBot/cmd/internal/dbflags"
+	"github.com/urfave/cli/v2"
+)
+
+// statsCommand summarizes what the database holds
+func statsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "stats",
+		Usage: "Show how many users, guilds and trips are stored, and the most used timezones",
+		Flags: append(dbflags.Flags(),
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of most used timezones to list",
+				Value: 10,
+			},
+		),
+		Action: func(c *cli.Context) error {
+			store, err := openStore(c)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			stats, err := store.GetStats(c.Context)
+			if err != nil {
+				return fmt.Errorf("error getting stats: %w", err)
+			}
+			zones, err := store.ListPopularTimezones(c.Context, int32(max(c.Int("top"), 0)))
+			if err != nil {
+				return fmt.Errorf("error listing timezones: %w", err)
+			}
+
+			w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "users\t%d\n", stats.Users)
+			fmt.Fprintf(w, "guild timezones\t%d\n", stats.GuildTimezones)
+			fmt.Fprintf(w, "guild defaults\t%d\n", stats.GuildDefaults)
+			fmt.Fprintf(w, "trips\t%d\n", stats.Travels)
+			fmt.Fprintf(w, "channel settings\t%d\n", stats.ChannelSettings)
+			fmt.Fprintf(w, "cooldowns\t%d\n", stats.Cooldowns)
+			if len(zones) > 0 {
+				fmt.Fprintln(w, "\ntimezone\tusers")
+				for _, zone := range zones {
+					fmt.Fprintf(w, "%s\t%d\n", zone.Timezone, zone.Users)
+				}
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/SHA65536/TimezoneBot/cmd/internal/dbflags"
+	"github.com/SHA65536/TimezoneBot/transfer"
+	"github.com/urfave/cli/v2"
+)
+
+// formatFlag picks the file format, guessed from the file name when not given
+func formatFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "format",
+		Usage: "File format (jsonl, csv), from the file extension by default",
+	}
+}
+
+// exportCommand dumps the saved user timezones
+func exportCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "export",
+		Usage:     "Write every saved user timezone to a JSON Lines or CSV file",
+		ArgsUsage: "[file, stdout when omitted or -]",
+		Flags:     append(dbflags.Flags(), formatFlag()),
+		Action: func(c *cli.Context) error {
+			path := c.Args().First()
+			format, err := transfer.ParseFormat(c.String("format"), path)
+			if err != nil {
+				return err
+			}
+
+			store, err := openStore(c)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			out := c.App.Writer
+			var file *os.File
+			if path != "" && path != "-" {
+				if file, err = os.Create(path); err != nil {
+					return err
+				}
+				defer file.Close()
+				out = file
+			}
+
+			res, err := transfer.Export(c.Context, store, transfer.NewWriter(out, format))
+			if err != nil {
+				return err
+			}
+			// A failed close can lose the end of the file
+			if file != nil {
+				if err := file.Close(); err != nil {
+					return err
+				}
+			}
+
+			for _, rec := range res.Invalid {
+				slog.Warn("left out invalid timezone", slog.String("user_id", rec.UserID), slog.String("timezone", rec.Timezone))
+			}
+			slog.Info("exported timezones", slog.Int("exported", res.Exported), slog.Int("invalid", len(res.Invalid)))
+			return nil
+		},
+	}
+}
+
+// importCommand saves the user timezones of an export, replacing the ones already saved.
+// A running bot may keep using a cached zone until its cache expires.
+func importCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "import",
+		Usage:     "Save the user timezones of a JSON Lines or CSV file made by export",
+		ArgsUsage: "<file, - for stdin>",
+		Flags: append(dbflags.Flags(),
+			formatFlag(),
+			&cli.IntFlag{
+				Name:  "batch-size",
+				Usage: "Number of timezones saved per transaction",
+				Value: 500,
+			},
+		),
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("expected 1 argument, usage: %s %s", c.Command.HelpName, c.Command.ArgsUsage)
+			}
+			path := c.Args().First()
+			format, err := transfer.ParseFormat(c.String("format"), path)
+			if err != nil {
+				return err
+			}
+
+			var in io.Reader = os.Stdin
+			if path != "-" {
+				f, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+
+			store, err := openStore(c)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			res, err := transfer.Import(c.Context, store, transfer.NewReader(in, format), c.Int("batch-size"))
+			logImport := func(msg string) {
+				slog.Info(msg, slog.Int("created", res.Created), slog.Int("updated", res.Updated), slog.Int("unchanged", res.Unchanged))
+			}
+			if err != nil {
+				if res != (transfer.ImportResult{}) {
+					logImport("imported timezones before failing")
+				}
+				return err
+			}
+			logImport("imported timezones")
+			return nil
+		},
+	}
+}
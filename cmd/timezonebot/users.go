@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/cmd/internal/dbflags"
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/urfave/cli/v2"
+)
+
+// usersCommand manages the timezones users saved. A running bot may keep using a cached
+// zone until its cache expires.
+func usersCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "users",
+		Usage: "Look up and change the timezones of users",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "get",
+				Usage:     "Show what is stored about a user",
+				ArgsUsage: "<user-id>",
+				Flags:     dbflags.Flags(),
+				Action:    getUser,
+			},
+			{
+				Name:      "set",
+				Usage:     "Set the timezone of a user, ending any ongoing trip",
+				ArgsUsage: "<user-id> <timezone>",
+				Flags:     dbflags.Flags(),
+				Action:    setUser,
+			},
+			{
+				Name:      "delete",
+				Usage:     "Remove everything stored about a user, like /privacy delete",
+				ArgsUsage: "<user-id>",
+				Flags:     dbflags.Flags(),
+				Action:    deleteUser,
+			},
+		},
+	}
+}
+
+func getUser(c *cli.Context) error {
+	userID, err := userArg(c, 1)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	timezone, err := optional(store.GetTimezone(c.Context, userID))
+	if err != nil {
+		return fmt.Errorf("error getting timezone: %w", err)
+	}
+	style, err := optional(store.GetUserTimestampStyle(c.Context, userID))
+	if err != nil {
+		return fmt.Errorf("error getting timestamp style: %w", err)
+	}
+	travel, err := store.GetTravel(c.Context, userID)
+	if err != nil && !errors.Is(err, database.ErrNoRows) {
+		return fmt.Errorf("error getting travel: %w", err)
+	}
+
+	w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "user\t%s\n", userID)
+	fmt.Fprintf(w, "timezone\t%s\n", orNone(timezone))
+	fmt.Fprintf(w, "timestamp style\t%s\n", orNone(style))
+	if err == nil {
+		fmt.Fprintf(w, "travelling until\t%s, then back to %s\n", travel.ExpiresAt.Format(time.RFC3339), travel.HomeTimezone)
+	}
+	return w.Flush()
+}
+
+func setUser(c *cli.Context) error {
+	userID, err := userArg(c, 2)
+	if err != nil {
+		return err
+	}
+	timezone := c.Args().Get(1)
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return fmt.Errorf("unknown timezone: %s", timezone)
+	}
+
+	store, err := openStore(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// Like /timezone, a new timezone ends any ongoing trip, which would otherwise revert it
+	err = store.InTx(c.Context, func(q database.Querier) error {
+		if err := q.DeleteTravel(c.Context, userID); err != nil {
+			return err
+		}
+		return q.SetTimezone(c.Context, database.SetTimezoneParams{UserID: userID, Timezone: timezone})
+	})
+	if err != nil {
+		return fmt.Errorf("error setting timezone: %w", err)
+	}
+	fmt.Fprintf(c.App.Writer, "Set the timezone of %s to %s\n", userID, timezone)
+	return nil
+}
+
+func deleteUser(c *cli.Context) error {
+	userID, err := userArg(c, 1)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	err = store.InTx(c.Context, func(q database.Querier) error {
+		return database.DeleteUserData(c.Context, q, userID)
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting user data: %w", err)
+	}
+	fmt.Fprintf(c.App.Writer, "Deleted everything stored about %s\n", userID)
+	return nil
+}
+
+// userArg checks the command got count arguments, the first being a user ID, and returns it
+func userArg(c *cli.Context, count int) (string, error) {
+	if c.NArg() != count {
+		return "", fmt.Errorf("expected %d arguments, usage: %s %s", count, c.Command.HelpName, c.Command.ArgsUsage)
+	}
+	userID := c.Args().First()
+	for _, r := range userID {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("invalid user ID: %s", userID)
+		}
+	}
+	return userID, nil
+}
+
+// optional returns an empty value instead of ErrNoRows for settings the user may not have
+func optional(value string, err error) (string, error) {
+	if errors.Is(err, database.ErrNoRows) {
+		return "", nil
+	}
+	return value, err
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
@@ -0,0 +1,16 @@
+package cooldown
+
+import (
+	"context"
+	"time"
+)
+
+// Store tracks cooldowns by key, such as the ID of a converted message
+type Store interface {
+	// Acquire starts a cooldown of d for key, reporting false if one is already running
+	Acquire(ctx context.Context, key string, d time.Duration) (bool, error)
+	// Release ends the cooldown for key early, e.g. when the guarded action failed
+	Release(ctx context.Context, key string) error
+	// Sweep removes expired cooldowns
+	Sweep(ctx context.Context) error
+}
@@ -0,0 +1,41 @@
+package cooldown
+
+import (
+	"context"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+)
+
+// Database is a Store kept in the cooldowns table, shared by every bot instance using it
+type Database struct {
+	db database.Querier
+}
+
+// NewDatabase creates a Store backed by the cooldowns table
+func NewDatabase(db database.Querier) *Database {
+	return &Database{db: db}
+}
+
+// Acquire starts a cooldown of d for key, reporting false if one is already running
+func (s *Database) Acquire(ctx context.Context, key string, d time.Duration) (bool, error) {
+	rows, err := s.db.AcquireCooldown(ctx, database.AcquireCooldownParams{
+		Key:     key,
+		Seconds: d.Seconds(),
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// Release ends the cooldown for key early
+func (s *Database) Release(ctx context.Context, key string) error {
+	return s.db.ReleaseCooldown(ctx, key)
+}
+
+// Sweep removes expired cooldowns
+func (s *Database) Sweep(ctx context.Context) error {
+	_, err := s.db.SweepCooldowns(ctx)
+	return err
+}
@@ -0,0 +1,95 @@
+package cooldown
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process Store holding at most a fixed number of cooldowns,
+// evicting the least recently used one when full
+type Memory struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // front is the most recently used entry
+	now      func() time.Time
+}
+
+type entry struct {
+	key     string
+	expires time.Time
+}
+
+// NewMemory creates a Memory store holding up to capacity cooldowns
+func NewMemory(capacity int) *Memory {
+	return &Memory{
+		capacity: capacity,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Acquire starts a cooldown of d for key, reporting false if one is already running
+func (m *Memory) Acquire(_ context.Context, key string, d time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if el, ok := m.items[key]; ok {
+		e := el.Value.(*entry)
+		m.order.MoveToFront(el)
+		if now.Before(e.expires) {
+			return false, nil
+		}
+		e.expires = now.Add(d)
+		return true, nil
+	}
+
+	m.items[key] = m.order.PushFront(&entry{key: key, expires: now.Add(d)})
+	for m.capacity > 0 && m.order.Len() > m.capacity {
+		m.remove(m.order.Back())
+	}
+	return true, nil
+}
+
+// Release ends the cooldown for key early
+func (m *Memory) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+	return nil
+}
+
+// Sweep removes expired cooldowns
+func (m *Memory) Sweep(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for el := m.order.Back(); el != nil; {
+		prev := el.Prev()
+		if !now.Before(el.Value.(*entry).expires) {
+			m.remove(el)
+		}
+		el = prev
+	}
+	return nil
+}
+
+// Len returns the number of tracked cooldowns, including expired ones not yet swept
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+func (m *Memory) remove(el *list.Element) {
+	m.order.Remove(el)
+	delete(m.items, el.Value.(*entry).key)
+}
@@ -0,0 +1,78 @@
+package cooldown
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a controllable time source for Memory
+func fakeClock(m *Memory) *time.Time {
+	now := time.Date(2025, 7, 12, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return &now
+}
+
+func TestMemory_Acquire(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+	now := fakeClock(m)
+
+	if ok, _ := m.Acquire(ctx, "a", time.Minute); !ok {
+		t.Errorf("Acquire() = false on a fresh key, want true")
+	}
+	if ok, _ := m.Acquire(ctx, "a", time.Minute); ok {
+		t.Errorf("Acquire() = true during cooldown, want false")
+	}
+
+	*now = now.Add(time.Minute)
+	if ok, _ := m.Acquire(ctx, "a", time.Minute); !ok {
+		t.Errorf("Acquire() = false after cooldown expired, want true")
+	}
+
+	m.Release(ctx, "a")
+	if ok, _ := m.Acquire(ctx, "a", time.Minute); !ok {
+		t.Errorf("Acquire() = false after Release(), want true")
+	}
+}
+
+func TestMemory_Sweep(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+	now := fakeClock(m)
+
+	m.Acquire(ctx, "short", time.Minute)
+	m.Acquire(ctx, "long", time.Hour)
+
+	*now = now.Add(2 * time.Minute)
+	m.Sweep(ctx)
+
+	if m.Len() != 1 {
+		t.Errorf("Len() = %d after Sweep(), want 1", m.Len())
+	}
+	if ok, _ := m.Acquire(ctx, "long", time.Minute); ok {
+		t.Errorf("Acquire() = true for a cooldown that should survive Sweep(), want false")
+	}
+}
+
+func TestMemory_Capacity(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+	fakeClock(m)
+
+	m.Acquire(ctx, "a", time.Hour)
+	m.Acquire(ctx, "b", time.Hour)
+	// Touch "a" so "b" becomes the least recently used entry
+	m.Acquire(ctx, "a", time.Hour)
+	m.Acquire(ctx, "c", time.Hour)
+
+	if m.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", m.Len())
+	}
+	if ok, _ := m.Acquire(ctx, "b", time.Hour); !ok {
+		t.Errorf("Acquire() = false for an evicted key, want true")
+	}
+	if ok, _ := m.Acquire(ctx, "c", time.Hour); ok {
+		t.Errorf("Acquire() = true for a key that should still be cooling down, want false")
+	}
+}
@@ -0,0 +1,284 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats counts lookups served by a CachedStore
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// CachedStore wraps a Store with a read-through cache for the timezone and channel mode
+// lookups done for every message. Users without a timezone are cached too, since most
+// message authors never set one.
+type CachedStore struct {
+	Store
+	lookups *lruCache[string]
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewCachedStore caches up to size timezone and channel mode lookups of store for ttl
+func NewCachedStore(store Store, ttl time.Duration, size int) *CachedStore {
+	return &CachedStore{
+		Store:   store,
+		lookups: newLRUCache[string](ttl, size),
+	}
+}
+
+// Cache keys of the different lookups
+func userTimezoneKey(userID string) string           { return "user:" + userID }
+func guildTimezoneKey(guildID, userID string) string { return "member:" + guildID + ":" + userID }
+func guildDefaultTimezoneKey(guildID string) string  { return "guild:" + guildID }
+func channelModeKey(channelID string) string         { return "channel:" + channelID }
+
+// GetTimezone returns the user's timezone, only querying the database on a cache miss
+func (c *CachedStore) GetTimezone(ctx context.Context, userID string) (string, error) {
+	return c.cached(userTimezoneKey(userID), func() (string, error) {
+		return c.Store.GetTimezone(ctx, userID)
+	})
+}
+
+// GetGuildTimezone returns the user's timezone in a guild, only querying the database on a cache miss
+func (c *CachedStore) GetGuildTimezone(ctx context.Context, arg GetGuildTimezoneParams) (string, error) {
+	return c.cached(guildTimezoneKey(arg.GuildID, arg.UserID), func() (string, error) {
+		return c.Store.GetGuildTimezone(ctx, arg)
+	})
+}
+
+// GetGuildDefaultTimezone returns the guild's default timezone, only querying the database on a cache miss
+func (c *CachedStore) GetGuildDefaultTimezone(ctx context.Context, guildID string) (string, error) {
+	return c.cached(guildDefaultTimezoneKey(guildID), func() (string, error) {
+		return c.Store.GetGuildDefaultTimezone(ctx, guildID)
+	})
+}
+
+// GetChannelMode returns the channel's mode, only querying the database on a cache miss
+func (c *CachedStore) GetChannelMode(ctx context.Context, channelID string) (string, error) {
+	return c.cached(channelModeKey(channelID), func() (string, error) {
+		return c.Store.GetChannelMode(ctx, channelID)
+	})
+}
+
+// SetTimezone saves the user's timezone and drops the cached one
+func (c *CachedStore) SetTimezone(ctx context.Context, arg SetTimezoneParams) error {
+	defer c.lookups.delete(userTimezoneKey(arg.UserID))
+	return c.Store.SetTimezone(ctx, arg)
+}
+
+// DeleteTimezone removes the user's timezone and drops the cached one
+func (c *CachedStore) DeleteTimezone(ctx context.Context, userID string) error {
+	defer c.lookups.delete(userTimezoneKey(userID))
+	return c.Store.DeleteTimezone(ctx, userID)
+}
+
+// SetGuildTimezone saves the user's timezone in a guild and drops the cached one
+func (c *CachedStore) SetGuildTimezone(ctx context.Context, arg SetGuildTimezoneParams) error {
+	defer c.lookups.delete(guildTimezoneKey(arg.GuildID, arg.UserID))
+	return c.Store.SetGuildTimezone(ctx, arg)
+}
+
+// DeleteGuildTimezone removes the user's timezone in a guild and drops the cached one
+func (c *CachedStore) DeleteGuildTimezone(ctx context.Context, arg DeleteGuildTimezoneParams) error {
+	defer c.lookups.delete(guildTimezoneKey(arg.GuildID, arg.UserID))
+	return c.Store.DeleteGuildTimezone(ctx, arg)
+}
+
+// SetGuildDefaultTimezone saves the guild's default timezone and drops the cached one
+func (c *CachedStore) SetGuildDefaultTimezone(ctx context.Context, arg SetGuildDefaultTimezoneParams) error {
+	defer c.lookups.delete(guildDefaultTimezoneKey(arg.GuildID))
+	return c.Store.SetGuildDefaultTimezone(ctx, arg)
+}
+
+// DeleteGuildDefaultTimezone removes the guild's default timezone and drops the cached one
+func (c *CachedStore) DeleteGuildDefaultTimezone(ctx context.Context, guildID string) error {
+	defer c.lookups.delete(guildDefaultTimezoneKey(guildID))
+	return c.Store.DeleteGuildDefaultTimezone(ctx, guildID)
+}
+
+// SetChannelMode saves the channel's mode and drops the cached one
+func (c *CachedStore) SetChannelMode(ctx context.Context, arg SetChannelModeParams) error {
+	defer c.lookups.delete(channelModeKey(arg.ChannelID))
+	return c.Store.SetChannelMode(ctx, arg)
+}
+
+// InTx runs fn in a transaction of the wrapped store, dropping the cached
+// lookups it changed once the transaction is over
+func (c *CachedStore) InTx(ctx context.Context, fn func(Querier) error) error {
+	tx := &invalidatingQuerier{}
+	defer func() {
+		for _, key := range tx.keys {
+			c.lookups.delete(key)
+		}
+	}()
+
+	return c.Store.InTx(ctx, func(q Querier) error {
+		tx.Querier = q
+		return fn(tx)
+	})
+}
+
+// Stats returns the number of cache hits and misses so far
+func (c *CachedStore) Stats() CacheStats {
+	return CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// cached returns the cached result for key, calling fetch and caching its result on a miss.
+// Only successful lookups and ErrNoRows are cached, other errors are left to be retried.
+// A result is dropped if a write invalidated the cache while it was fetched, since it
+// may have been read before that write.
+func (c *CachedStore) cached(key string, fetch func() (string, error)) (string, error) {
+	if value, err, ok := c.lookups.get(key); ok {
+		c.hits.Add(1)
+		return value, err
+	}
+	c.misses.Add(1)
+
+	gen := c.lookups.generation()
+	value, err := fetch()
+	if err == nil || errors.Is(err, ErrNoRows) {
+		c.lookups.set(key, value, err, gen)
+	}
+	return value, err
+}
+
+// invalidatingQuerier remembers which cached lookups were changed in a transaction
+type invalidatingQuerier struct {
+	Querier
+	keys []string
+}
+
+func (q *invalidatingQuerier) SetTimezone(ctx context.Context, arg SetTimezoneParams) error {
+	q.keys = append(q.keys, userTimezoneKey(arg.UserID))
+	return q.Querier.SetTimezone(ctx, arg)
+}
+
+func (q *invalidatingQuerier) DeleteTimezone(ctx context.Context, userID string) error {
+	q.keys = append(q.keys, userTimezoneKey(userID))
+	return q.Querier.DeleteTimezone(ctx, userID)
+}
+
+func (q *invalidatingQuerier) SetGuildTimezone(ctx context.Context, arg SetGuildTimezoneParams) error {
+	q.keys = append(q.keys, guildTimezoneKey(arg.GuildID, arg.UserID))
+	return q.Querier.SetGuildTimezone(ctx, arg)
+}
+
+func (q *invalidatingQuerier) DeleteGuildTimezone(ctx context.Context, arg DeleteGuildTimezoneParams) error {
+	q.keys = append(q.keys, guildTimezoneKey(arg.GuildID, arg.UserID))
+	return q.Querier.DeleteGuildTimezone(ctx, arg)
+}
+
+func (q *invalidatingQuerier) SetGuildDefaultTimezone(ctx context.Context, arg SetGuildDefaultTimezoneParams) error {
+	q.keys = append(q.keys, guildDefaultTimezoneKey(arg.GuildID))
+	return q.Querier.SetGuildDefaultTimezone(ctx, arg)
+}
+
+func (q *invalidatingQuerier) DeleteGuildDefaultTimezone(ctx context.Context, guildID string) error {
+	q.keys = append(q.keys, guildDefaultTimezoneKey(guildID))
+	return q.Querier.DeleteGuildDefaultTimezone(ctx, guildID)
+}
+
+func (q *invalidatingQuerier) SetChannelMode(ctx context.Context, arg SetChannelModeParams) error {
+	q.keys = append(q.keys, channelModeKey(arg.ChannelID))
+	return q.Querier.SetChannelMode(ctx, arg)
+}
+
+// lruCache is a size bounded cache whose entries expire after a fixed TTL
+type lruCache[V any] struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // front is the most recently used entry
+	gen      uint64     // bumped by every delete
+}
+
+type cacheEntry[V any] struct {
+	key     string
+	value   V
+	err     error
+	expires time.Time
+}
+
+func newLRUCache[V any](ttl time.Duration, capacity int) *lruCache[V] {
+	return &lruCache[V]{
+		ttl:      ttl,
+		capacity: capacity,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// get returns the cached value and error for key, ok is false if there is none or it expired
+func (c *lruCache[V]) get(key string) (value V, err error, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, found := c.items[key]
+	if !found {
+		return value, nil, false
+	}
+	e := el.Value.(*cacheEntry[V])
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		return value, nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, e.err, true
+}
+
+// generation returns a value that changes whenever an entry is deleted
+func (c *lruCache[V]) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// set caches a lookup result fetched at generation gen, evicting the least recently
+// used entry when full. Nothing is cached if a delete happened since gen.
+func (c *lruCache[V]) set(key string, value V, err error, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	e := &cacheEntry[V]{key: key, value: value, err: err, expires: time.Now().Add(c.ttl)}
+	if el, found := c.items[key]; found {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(e)
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// delete drops key from the cache
+func (c *lruCache[V]) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if el, found := c.items[key]; found {
+		c.remove(el)
+	}
+}
+
+func (c *lruCache[V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*cacheEntry[V]).key)
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingStore serves timezones and channel modes from maps and counts how often it was asked
+type countingStore struct {
+	Store
+	timezones map[string]string
+	modes     map[string]string
+	calls     int
+}
+
+func (q *countingStore) GetTimezone(_ context.Context, userID string) (string, error) {
+	q.calls++
+	timezone, ok := q.timezones[userID]
+	if !ok {
+		return "", ErrNoRows
+	}
+	return timezone, nil
+}
+
+func (q *countingStore) SetTimezone(_ context.Context, arg SetTimezoneParams) error {
+	q.timezones[arg.UserID] = arg.Timezone
+	return nil
+}
+
+func (q *countingStore) GetChannelMode(_ context.Context, channelID string) (string, error) {
+	q.calls++
+	mode, ok := q.modes[channelID]
+	if !ok {
+		return "", ErrNoRows
+	}
+	return mode, nil
+}
+
+func (q *countingStore) SetChannelMode(_ context.Context, arg SetChannelModeParams) error {
+	q.modes[arg.ChannelID] = arg.Mode
+	return nil
+}
+
+func (q *countingStore) InTx(_ context.Context, fn func(Querier) error) error {
+	return fn(q)
+}
+
+func TestCachedStore_GetTimezone(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStore{timezones: map[string]string{"1": "Europe/London"}}
+	cache := NewCachedStore(backend, time.Minute, 10)
+
+	for range 3 {
+		timezone, err := cache.GetTimezone(ctx, "1")
+		if err != nil || timezone != "Europe/London" {
+			t.Fatalf("GetTimezone() = %q, %v, want Europe/London", timezone, err)
+		}
+	}
+
+	// Users without a timezone are cached as well
+	for range 3 {
+		if _, err := cache.GetTimezone(ctx, "2"); !errors.Is(err, ErrNoRows) {
+			t.Fatalf("GetTimezone() error = %v, want ErrNoRows", err)
+		}
+	}
+
+	if backend.calls != 2 {
+		t.Errorf("backend called %d times, want 2", backend.calls)
+	}
+	if stats := cache.Stats(); stats.Hits != 4 || stats.Misses != 2 {
+		t.Errorf("Stats() = %+v, want 4 hits and 2 misses", stats)
+	}
+}
+
+func TestCachedStore_SetTimezoneInvalidates(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStore{timezones: map[string]string{}}
+	cache := NewCachedStore(backend, time.Minute, 10)
+
+	if _, err := cache.GetTimezone(ctx, "1"); !errors.Is(err, ErrNoRows) {
+		t.Fatalf("GetTimezone() error = %v, want ErrNoRows", err)
+	}
+
+	cache.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: "Asia/Tokyo"})
+
+	if timezone, err := cache.GetTimezone(ctx, "1"); err != nil || timezone != "Asia/Tokyo" {
+		t.Errorf("GetTimezone() = %q, %v after SetTimezone(), want Asia/Tokyo", timezone, err)
+	}
+}
+
+// slowStore signals fetched once it read a timezone and waits for release before returning it
+type slowStore struct {
+	*countingStore
+	fetched chan struct{}
+	release chan struct{}
+}
+
+func (q *slowStore) GetTimezone(ctx context.Context, userID string) (string, error) {
+	timezone, err := q.countingStore.GetTimezone(ctx, userID)
+	q.fetched <- struct{}{}
+	<-q.release
+	return timezone, err
+}
+
+func TestCachedStore_SetDuringFetch(t *testing.T) {
+	ctx := context.Background()
+	backend := &slowStore{
+		countingStore: &countingStore{timezones: map[string]string{"1": "Europe/London"}},
+		fetched:       make(chan struct{}, 2),
+		release:       make(chan struct{}),
+	}
+	cache := NewCachedStore(backend, time.Minute, 10)
+
+	done := make(chan string)
+	go func() {
+		timezone, _ := cache.GetTimezone(ctx, "1")
+		done <- timezone
+	}()
+
+	// The timezone changes after the lookup read the old one but before it is cached
+	<-backend.fetched
+	cache.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: "Asia/Tokyo"})
+	close(backend.release)
+	if timezone := <-done; timezone != "Europe/London" {
+		t.Fatalf("slow GetTimezone() = %q, want Europe/London", timezone)
+	}
+
+	if timezone, err := cache.GetTimezone(ctx, "1"); err != nil || timezone != "Asia/Tokyo" {
+		t.Errorf("GetTimezone() = %q, %v after a SetTimezone() raced a lookup, want Asia/Tokyo", timezone, err)
+	}
+}
+
+func TestCachedStore_Bounds(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStore{timezones: map[string]string{"1": "UTC", "2": "UTC", "3": "UTC"}}
+
+	cache := NewCachedStore(backend, time.Minute, 2)
+	cache.GetTimezone(ctx, "1")
+	cache.GetTimezone(ctx, "2")
+	cache.GetTimezone(ctx, "3")
+	cache.GetTimezone(ctx, "1")
+	if backend.calls != 4 {
+		t.Errorf("backend called %d times with an evicted entry, want 4", backend.calls)
+	}
+
+	backend.calls = 0
+	cache = NewCachedStore(backend, -time.Second, 10)
+	cache.GetTimezone(ctx, "1")
+	cache.GetTimezone(ctx, "1")
+	if backend.calls != 2 {
+		t.Errorf("backend called %d times with expired entries, want 2", backend.calls)
+	}
+}
+
+func TestCachedStore_ChannelMode(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStore{modes: map[string]string{}}
+	cache := NewCachedStore(backend, time.Minute, 10)
+
+	for range 3 {
+		if _, err := cache.GetChannelMode(ctx, "20"); !errors.Is(err, ErrNoRows) {
+			t.Fatalf("GetChannelMode() error = %v, want ErrNoRows", err)
+		}
+	}
+	if backend.calls != 1 {
+		t.Errorf("backend called %d times, want 1", backend.calls)
+	}
+
+	cache.SetChannelMode(ctx, SetChannelModeParams{ChannelID: "20", GuildID: "10", Mode: "off"})
+	if mode, err := cache.GetChannelMode(ctx, "20"); err != nil || mode != "off" {
+		t.Errorf("GetChannelMode() = %q, %v after SetChannelMode(), want off", mode, err)
+	}
+
+	cache.InTx(ctx, func(q Querier) error {
+		return q.SetChannelMode(ctx, SetChannelModeParams{ChannelID: "20", GuildID: "10", Mode: "auto-reply"})
+	})
+	if mode, err := cache.GetChannelMode(ctx, "20"); err != nil || mode != "auto-reply" {
+		t.Errorf("GetChannelMode() = %q, %v after a transaction set it, want auto-reply", mode, err)
+	}
+}
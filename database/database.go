@@ -2,28 +2,145 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-const CONN_STR = "postgres://%s:%s@%s:%s/%s"
+// Supported database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
 
 type DatabaseConfig struct {
+	Driver string
+	// URL is a full postgres:// connection string, used instead of the fields below when set
+	URL  string
 	Host string
 	Port string
 	User string
 	Pass string
 	Name string
+	Path string
+
+	// SSLMode is passed to Postgres as sslmode, e.g. disable, require or verify-full
+	SSLMode string
+	// StatementTimeout aborts Postgres statements running longer than this, 0 means no limit
+	StatementTimeout time.Duration
+	// MaxConns and MinConns size the connection pool, 0 keeps the pgxpool defaults
+	MaxConns int32
+	MinConns int32
+}
+
+// MakeDatabase connects to the database described by cfg
+func MakeDatabase(cfg DatabaseConfig) (Store, error) {
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		connStr, err := cfg.ConnString()
+		if err != nil {
+			return nil, err
+		}
+
+		poolCfg, err := pgxpool.ParseConfig(connStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connection string: %w", err)
+		}
+		if cfg.MaxConns > 0 {
+			poolCfg.MaxConns = cfg.MaxConns
+		}
+		if cfg.MinConns > 0 {
+			poolCfg.MinConns = cfg.MinConns
+		}
+		poolCfg.ConnConfig.Tracer = pgxTracer{}
+
+		pool, err := pgxpool.NewWithConfig(context.TODO(), poolCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewPostgresStore(pool), nil
+	case DriverSQLite:
+		db, err := openSQLite(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewSQLiteStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", cfg.Driver)
+	}
 }
 
-func MakeDatabase(cfg DatabaseConfig) (*Queries, error) {
-	connStr := fmt.Sprintf(CONN_STR, cfg.User, cfg.Pass, cfg.Host, cfg.Port, cfg.Name)
+// ConnString builds the Postgres connection string, escaping credentials and
+// adding the sslmode and statement_timeout options when they are set. A
+// sslmode already in URL wins over SSLMode.
+func (cfg DatabaseConfig) ConnString() (string, error) {
+	var u *url.URL
+	if cfg.URL != "" {
+		parsed, err := url.Parse(cfg.URL)
+		if err != nil {
+			// url.Error repeats the whole URL, password included
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return "", fmt.Errorf("invalid database url: %w", err)
+		}
+		if parsed.Scheme != "postgres" && parsed.Scheme != "postgresql" {
+			return "", fmt.Errorf("invalid database url: must start with postgres:// or postgresql://")
+		}
+		if parsed.Host == "" {
+			return "", fmt.Errorf("invalid database url: missing host")
+		}
+		u = parsed
+	} else {
+		u = &url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(cfg.User, cfg.Pass),
+			Host:   net.JoinHostPort(cfg.Host, cfg.Port),
+			Path:   "/" + cfg.Name,
+		}
+	}
 
-	pool, err := pgxpool.New(context.TODO(), connStr)
-	if err != nil {
-		return nil, err
+	query := u.Query()
+	if cfg.SSLMode != "" && !query.Has("sslmode") {
+		query.Set("sslmode", cfg.SSLMode)
+	}
+	if cfg.StatementTimeout > 0 {
+		query.Set("statement_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
 	}
+	u.RawQuery = query.Encode()
 
-	return New(pool), nil
+	return u.String(), nil
+}
+
+// Validate checks that cfg has everything its driver needs
+func (cfg DatabaseConfig) Validate() error {
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		if cfg.URL == "" && (cfg.Host == "" || cfg.Port == "" || cfg.User == "" || cfg.Pass == "" || cfg.Name == "") {
+			return fmt.Errorf("postgres requires db-url, or db-host, db-port, db-user, db-pass and db-name")
+		}
+		if cfg.URL != "" {
+			if _, err := cfg.ConnString(); err != nil {
+				return err
+			}
+		}
+		if cfg.MinConns > 0 && cfg.MaxConns > 0 && cfg.MinConns > cfg.MaxConns {
+			return fmt.Errorf("db-min-conns can't be larger than db-max-conns")
+		}
+	case DriverSQLite:
+		if cfg.Path == "" {
+			return fmt.Errorf("sqlite requires db-path")
+		}
+	default:
+		return fmt.Errorf("unknown database driver: %s", cfg.Driver)
+	}
+	return nil
 }
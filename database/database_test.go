@@ -0,0 +1,115 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestDatabaseConfig_ConnString(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      DatabaseConfig
+		expected string
+	}{
+		{"plain", DatabaseConfig{Host: "db", Port: "5432", User: "bot", Pass: "secret", Name: "timezones"},
+			"postgres://bot:secret@db:5432/timezones"},
+		{"escaped password", DatabaseConfig{Host: "db", Port: "5432", User: "bot", Pass: "p@ss/w:rd?", Name: "timezones"},
+			"postgres://bot:p%40ss%2Fw%3Ard%3F@db:5432/timezones"},
+		{"ipv6 host", DatabaseConfig{Host: "::1", Port: "5432", User: "bot", Pass: "secret", Name: "timezones"},
+			"postgres://bot:secret@[::1]:5432/timezones"},
+		{"options", DatabaseConfig{Host: "db", Port: "5432", User: "bot", Pass: "secret", Name: "timezones", SSLMode: "require", StatementTimeout: 5 * time.Second},
+			"postgres://bot:secret@db:5432/timezones?sslmode=require&statement_timeout=5000"},
+		{"url", DatabaseConfig{URL: "postgres://bot:secret@db/timezones?application_name=bot", Host: "ignored"},
+			"postgres://bot:secret@db/timezones?application_name=bot"},
+		{"url sslmode kept", DatabaseConfig{URL: "postgres://bot:secret@db/timezones?sslmode=disable", SSLMode: "verify-full"},
+			"postgres://bot:secret@db/timezones?sslmode=disable"},
+		{"url sslmode default", DatabaseConfig{URL: "postgresql://bot:secret@db/timezones", SSLMode: "verify-full"},
+			"postgresql://bot:secret@db/timezones?sslmode=verify-full"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.cfg.ConnString()
+			if err != nil {
+				t.Fatalf("ConnString() unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ConnString() = %v, want %v", result, tt.expected)
+			}
+
+			// pgx must read back the same credentials
+			if _, err := pgxpool.ParseConfig(result); err != nil {
+				t.Errorf("pgxpool.ParseConfig() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDatabaseConfig_ConnStringInvalidURL(t *testing.T) {
+	urls := []string{
+		"host=localhost user=bob password=secret dbname=timezones",
+		"mysql://bob:secret@db/timezones",
+		"postgres:///timezones",
+		"postgres://bob:secret@db/timezones\x7f",
+	}
+
+	for _, u := range urls {
+		t.Run(u, func(t *testing.T) {
+			_, err := DatabaseConfig{URL: u}.ConnString()
+			if err == nil {
+				t.Fatalf("ConnString() expected error but got none")
+			}
+			if strings.Contains(err.Error(), "secret") {
+				t.Errorf("ConnString() error %q leaks the password", err)
+			}
+		})
+	}
+}
+
+func TestDatabaseConfig_ConnStringCredentials(t *testing.T) {
+	cfg := DatabaseConfig{Host: "db", Port: "5432", User: "bot", Pass: "p@ss/w:rd?#", Name: "timezones"}
+	connStr, err := cfg.ConnString()
+	if err != nil {
+		t.Fatalf("ConnString() unexpected error: %v", err)
+	}
+
+	poolCfg, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		t.Fatalf("pgxpool.ParseConfig() unexpected error: %v", err)
+	}
+	if poolCfg.ConnConfig.Password != cfg.Pass || poolCfg.ConnConfig.Host != "db" || poolCfg.ConnConfig.Database != "timezones" {
+		t.Errorf("parsed config = %s@%s/%s, want %s@db/timezones", poolCfg.ConnConfig.Password, poolCfg.ConnConfig.Host, poolCfg.ConnConfig.Database, cfg.Pass)
+	}
+}
+
+func TestDatabaseConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      DatabaseConfig
+		hasError bool
+	}{
+		{"postgres fields", DatabaseConfig{Host: "db", Port: "5432", User: "bot", Pass: "secret", Name: "timezones"}, false},
+		{"postgres url", DatabaseConfig{URL: "postgres://db/timezones"}, false},
+		{"postgres missing fields", DatabaseConfig{Host: "db"}, true},
+		{"postgres key/value url", DatabaseConfig{URL: "host=db dbname=timezones"}, true},
+		{"postgres pool bounds", DatabaseConfig{URL: "postgres://db/timezones", MinConns: 5, MaxConns: 2}, true},
+		{"sqlite", DatabaseConfig{Driver: DriverSQLite, Path: "bot.db"}, false},
+		{"sqlite missing path", DatabaseConfig{Driver: DriverSQLite}, true},
+		{"unknown driver", DatabaseConfig{Driver: "mysql"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.hasError && err == nil {
+				t.Errorf("Validate() expected error but got none")
+			}
+			if !tt.hasError && err != nil {
+				t.Errorf("Validate() unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"database/sql"
 	"embed"
 	"fmt"
 
@@ -8,24 +9,39 @@ import (
 	"github.com/pressly/goose/v3"
 )
 
-//go:embed sql/schemas/*.sql
+//go:embed sql/schemas/*.sql sql/sqlite/schemas/*.sql
 var embedMigrations embed.FS
 
 func RunMigrations(cfg DatabaseConfig) error {
 	goose.SetBaseFS(embedMigrations)
-	gooseConnStr := fmt.Sprintf(CONN_STR, cfg.User, cfg.Pass, cfg.Host, cfg.Port, cfg.Name)
 
-	db, err := goose.OpenDBWithDriver("postgres", gooseConnStr)
+	var db *sql.DB
+	var dialect, dir string
+	var err error
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		gooseConnStr, connErr := cfg.ConnString()
+		if connErr != nil {
+			return connErr
+		}
+		dialect, dir = "postgres", "sql/schemas"
+		db, err = goose.OpenDBWithDriver(dialect, gooseConnStr)
+	case DriverSQLite:
+		dialect, dir = "sqlite3", "sql/sqlite/schemas"
+		db, err = openSQLite(cfg.Path)
+	default:
+		return fmt.Errorf("unknown database driver: %s", cfg.Driver)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open database for migrations: %w", err)
 	}
 	defer db.Close()
 
-	if err := goose.SetDialect("postgres"); err != nil {
+	if err := goose.SetDialect(dialect); err != nil {
 		return fmt.Errorf("failed to set Goose dialect: %w", err)
 	}
 
-	if err := goose.Up(db, "sql/schemas"); err != nil {
+	if err := goose.Up(db, dir); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
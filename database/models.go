@@ -4,7 +4,64 @@
 
 package database
 
+import (
+	"time"
+)
+
+type ChannelSetting struct {
+	ChannelID string
+	GuildID   string
+	Mode      string
+}
+
+type Cooldown struct {
+	Key       string
+	ExpiresAt time.Time
+}
+
+type ConvertReply struct {
+	MessageID      string
+	ReplyChannelID string
+	ReplyID        string
+	UpdatedAt      time.Time
+}
+
+type GuildSetting struct {
+	GuildID         string
+	TimestampStyle  string
+	CooldownSeconds int32
+}
+
+type GuildTimezone struct {
+	GuildID  string
+	UserID   string
+	Timezone string
+}
+
+type GuildTimezoneDefault struct {
+	GuildID  string
+	Timezone string
+}
+
 type Timezone struct {
 	UserID   string
 	Timezone string
 }
+
+type TimezoneHistory struct {
+	ID        int64
+	UserID    string
+	Timezone  string
+	ChangedAt time.Time
+}
+
+type Travel struct {
+	UserID       string
+	HomeTimezone string
+	ExpiresAt    time.Time
+}
+
+type UserSetting struct {
+	UserID         string
+	TimestampStyle string
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// QueryObserver is told how long each query took and whether it failed
+type QueryObserver func(query string, took time.Duration, err error)
+
+// ObservedStore wraps a Store and reports the duration of every query to an observer,
+// including the ones run in transactions
+type ObservedStore struct {
+	observedQuerier
+	store Store
+}
+
+// NewObservedStore reports every query run on store to observe
+func NewObservedStore(store Store, observe QueryObserver) *ObservedStore {
+	return &ObservedStore{
+		observedQuerier: observedQuerier{Querier: store, observe: observe},
+		store:           store,
+	}
+}
+
+// InTx runs fn in a transaction of the wrapped store, observing the queries it runs
+func (s *ObservedStore) InTx(ctx context.Context, fn func(Querier) error) error {
+	return s.store.InTx(ctx, func(q Querier) error {
+		return fn(&observedQuerier{Querier: q, observe: s.observe})
+	})
+}
+
+// Ping checks the wrapped store can be reached
+func (s *ObservedStore) Ping(ctx context.Context) error {
+	return s.store.Ping(ctx)
+}
+
+// Close closes the wrapped store
+func (s *ObservedStore) Close() {
+	s.store.Close()
+}
+
+// observedQuerier times every query of the wrapped Querier
+type observedQuerier struct {
+	Querier
+	observe QueryObserver
+}
+
+// observed runs query and reports how long it took
+func observed[T any](q *observedQuerier, name string, query func() (T, error)) (T, error) {
+	start := time.Now()
+	result, err := query()
+	q.observe(name, time.Since(start), err)
+	return result, err
+}
+
+// observedExec runs a query without a result and reports how long it took
+func observedExec(q *observedQuerier, name string, query func() error) error {
+	start := time.Now()
+	err := query()
+	q.observe(name, time.Since(start), err)
+	return err
+}
+
+func (q *observedQuerier) AcquireCooldown(ctx context.Context, arg AcquireCooldownParams) (int64, error) {
+	return observed(q, "AcquireCooldown", func() (int64, error) { return q.Querier.AcquireCooldown(ctx, arg) })
+}
+
+func (q *observedQuerier) DeleteConvertRepliesBefore(ctx context.Context, updatedAt time.Time) (int64, error) {
+	return observed(q, "DeleteConvertRepliesBefore", func() (int64, error) { return q.Querier.DeleteConvertRepliesBefore(ctx, updatedAt) })
+}
+
+func (q *observedQuerier) DeleteConvertReply(ctx context.Context, messageID string) error {
+	return observedExec(q, "DeleteConvertReply", func() error { return q.Querier.DeleteConvertReply(ctx, messageID) })
+}
+
+func (q *observedQuerier) DeleteExpiredTravel(ctx context.Context, userID string) (int64, error) {
+	return observed(q, "DeleteExpiredTravel", func() (int64, error) { return q.Querier.DeleteExpiredTravel(ctx, userID) })
+}
+
+func (q *observedQuerier) DeleteGuildDefaultTimezone(ctx context.Context, guildID string) error {
+	return observedExec(q, "DeleteGuildDefaultTimezone", func() error { return q.Querier.DeleteGuildDefaultTimezone(ctx, guildID) })
+}
+
+func (q *observedQuerier) DeleteGuildTimezone(ctx context.Context, arg DeleteGuildTimezoneParams) error {
+	return observedExec(q, "DeleteGuildTimezone", func() error { return q.Querier.DeleteGuildTimezone(ctx, arg) })
+}
+
+func (q *observedQuerier) DeleteTimezone(ctx context.Context, userID string) error {
+	return observedExec(q, "DeleteTimezone", func() error { return q.Querier.DeleteTimezone(ctx, userID) })
+}
+
+func (q *observedQuerier) DeleteTimezoneHistory(ctx context.Context, userID string) error {
+	return observedExec(q, "DeleteTimezoneHistory", func() error { return q.Querier.DeleteTimezoneHistory(ctx, userID) })
+}
+
+func (q *observedQuerier) DeleteTravel(ctx context.Context, userID string) error {
+	return observedExec(q, "DeleteTravel", func() error { return q.Querier.DeleteTravel(ctx, userID) })
+}
+
+func (q *observedQuerier) DeleteUserSettings(ctx context.Context, userID string) error {
+	return observedExec(q, "DeleteUserSettings", func() error { return q.Querier.DeleteUserSettings(ctx, userID) })
+}
+
+func (q *observedQuerier) GetChannelMode(ctx context.Context, channelID string) (string, error) {
+	return observed(q, "GetChannelMode", func() (string, error) { return q.Querier.GetChannelMode(ctx, channelID) })
+}
+
+func (q *observedQuerier) GetConvertReply(ctx context.Context, messageID string) (GetConvertReplyRow, error) {
+	return observed(q, "GetConvertReply", func() (GetConvertReplyRow, error) { return q.Querier.GetConvertReply(ctx, messageID) })
+}
+
+func (q *observedQuerier) GetGuildCooldown(ctx context.Context, guildID string) (int32, error) {
+	return observed(q, "GetGuildCooldown", func() (int32, error) { return q.Querier.GetGuildCooldown(ctx, guildID) })
+}
+
+func (q *observedQuerier) GetGuildDefaultTimezone(ctx context.Context, guildID string) (string, error) {
+	return observed(q, "GetGuildDefaultTimezone", func() (string, error) { return q.Querier.GetGuildDefaultTimezone(ctx, guildID) })
+}
+
+func (q *observedQuerier) GetGuildTimestampStyle(ctx context.Context, guildID string) (string, error) {
+	return observed(q, "GetGuildTimestampStyle", func() (string, error) { return q.Querier.GetGuildTimestampStyle(ctx, guildID) })
+}
+
+func (q *observedQuerier) GetGuildTimezone(ctx context.Context, arg GetGuildTimezoneParams) (string, error) {
+	return observed(q, "GetGuildTimezone", func() (string, error) { return q.Querier.GetGuildTimezone(ctx, arg) })
+}
+
+func (q *observedQuerier) GetStats(ctx context.Context) (GetStatsRow, error) {
+	return observed(q, "GetStats", func() (GetStatsRow, error) { return q.Querier.GetStats(ctx) })
+}
+
+func (q *observedQuerier) GetTimezone(ctx context.Context, userID string) (string, error) {
+	return observed(q, "GetTimezone", func() (string, error) { return q.Querier.GetTimezone(ctx, userID) })
+}
+
+func (q *observedQuerier) GetTimezoneAt(ctx context.Context, arg GetTimezoneAtParams) (string, error) {
+	return observed(q, "GetTimezoneAt", func() (string, error) { return q.Querier.GetTimezoneAt(ctx, arg) })
+}
+
+func (q *observedQuerier) GetTravel(ctx context.Context, userID string) (Travel, error) {
+	return observed(q, "GetTravel", func() (Travel, error) { return q.Querier.GetTravel(ctx, userID) })
+}
+
+func (q *observedQuerier) GetUserTimestampStyle(ctx context.Context, userID string) (string, error) {
+	return observed(q, "GetUserTimestampStyle", func() (string, error) { return q.Querier.GetUserTimestampStyle(ctx, userID) })
+}
+
+func (q *observedQuerier) ListExpiredTravels(ctx context.Context) ([]Travel, error) {
+	return observed(q, "ListExpiredTravels", func() ([]Travel, error) { return q.Querier.ListExpiredTravels(ctx) })
+}
+
+func (q *observedQuerier) ListPopularTimezones(ctx context.Context, maxZones int32) ([]ListPopularTimezonesRow, error) {
+	return observed(q, "ListPopularTimezones", func() ([]ListPopularTimezonesRow, error) { return q.Querier.ListPopularTimezones(ctx, maxZones) })
+}
+
+func (q *observedQuerier) ListTimezoneHistory(ctx context.Context, userID string) ([]ListTimezoneHistoryRow, error) {
+	return observed(q, "ListTimezoneHistory", func() ([]ListTimezoneHistoryRow, error) { return q.Querier.ListTimezoneHistory(ctx, userID) })
+}
+
+func (q *observedQuerier) ListTimezones(ctx context.Context, arg ListTimezonesParams) ([]Timezone, error) {
+	return observed(q, "ListTimezones", func() ([]Timezone, error) { return q.Querier.ListTimezones(ctx, arg) })
+}
+
+func (q *observedQuerier) ListUserGuildTimezones(ctx context.Context, userID string) ([]ListUserGuildTimezonesRow, error) {
+	return observed(q, "ListUserGuildTimezones", func() ([]ListUserGuildTimezonesRow, error) { return q.Querier.ListUserGuildTimezones(ctx, userID) })
+}
+
+func (q *observedQuerier) ReleaseCooldown(ctx context.Context, key string) error {
+	return observedExec(q, "ReleaseCooldown", func() error { return q.Querier.ReleaseCooldown(ctx, key) })
+}
+
+func (q *observedQuerier) SetChannelMode(ctx context.Context, arg SetChannelModeParams) error {
+	return observedExec(q, "SetChannelMode", func() error { return q.Querier.SetChannelMode(ctx, arg) })
+}
+
+func (q *observedQuerier) SetConvertReply(ctx context.Context, arg SetConvertReplyParams) error {
+	return observedExec(q, "SetConvertReply", func() error { return q.Querier.SetConvertReply(ctx, arg) })
+}
+
+func (q *observedQuerier) SetGuildCooldown(ctx context.Context, arg SetGuildCooldownParams) error {
+	return observedExec(q, "SetGuildCooldown", func() error { return q.Querier.SetGuildCooldown(ctx, arg) })
+}
+
+func (q *observedQuerier) SetGuildDefaultTimezone(ctx context.Context, arg SetGuildDefaultTimezoneParams) error {
+	return observedExec(q, "SetGuildDefaultTimezone", func() error { return q.Querier.SetGuildDefaultTimezone(ctx, arg) })
+}
+
+func (q *observedQuerier) SetGuildTimestampStyle(ctx context.Context, arg SetGuildTimestampStyleParams) error {
+	return observedExec(q, "SetGuildTimestampStyle", func() error { return q.Querier.SetGuildTimestampStyle(ctx, arg) })
+}
+
+func (q *observedQuerier) SetGuildTimezone(ctx context.Context, arg SetGuildTimezoneParams) error {
+	return observedExec(q, "SetGuildTimezone", func() error { return q.Querier.SetGuildTimezone(ctx, arg) })
+}
+
+func (q *observedQuerier) SetTimezone(ctx context.Context, arg SetTimezoneParams) error {
+	return observedExec(q, "SetTimezone", func() error { return q.Querier.SetTimezone(ctx, arg) })
+}
+
+func (q *observedQuerier) SetTravel(ctx context.Context, arg SetTravelParams) error {
+	return observedExec(q, "SetTravel", func() error { return q.Querier.SetTravel(ctx, arg) })
+}
+
+func (q *observedQuerier) SetUserTimestampStyle(ctx context.Context, arg SetUserTimestampStyleParams) error {
+	return observedExec(q, "SetUserTimestampStyle", func() error { return q.Querier.SetUserTimestampStyle(ctx, arg) })
+}
+
+func (q *observedQuerier) SweepCooldowns(ctx context.Context) (int64, error) {
+	return observed(q, "SweepCooldowns", func() (int64, error) { return q.Querier.SweepCooldowns(ctx) })
+}
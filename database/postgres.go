@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore is a Store backed by a pgx connection pool
+type PostgresStore struct {
+	*Queries
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore creates a Store using pool
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{
+		Queries: New(pool),
+		pool:    pool,
+	}
+}
+
+// InTx runs fn in a transaction, committing it if fn returns nil and rolling it back otherwise
+func (s *PostgresStore) InTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Ping acquires a connection from the pool and checks it is alive
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
+
+// Close closes the connection pool
+func (s *PostgresStore) Close() {
+	s.pool.Close()
+}
@@ -0,0 +1,9 @@
+-- name: AcquireCooldown :execrows
+INSERT INTO cooldowns (key, expires_at) VALUES (@key, now() + make_interval(secs => @seconds::float8))
+ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at WHERE cooldowns.expires_at <= now();
+
+-- name: ReleaseCooldown :exec
+DELETE FROM cooldowns WHERE key = @key;
+
+-- name: SweepCooldowns :execrows
+DELETE FROM cooldowns WHERE expires_at <= now();
@@ -0,0 +1,12 @@
+-- name: GetConvertReply :one
+SELECT reply_channel_id, reply_id FROM convert_replies WHERE message_id = @message_id;
+
+-- name: SetConvertReply :exec
+INSERT INTO convert_replies (message_id, reply_channel_id, reply_id, updated_at) VALUES (@message_id, @reply_channel_id, @reply_id, now())
+ON CONFLICT (message_id) DO UPDATE SET reply_channel_id = @reply_channel_id, reply_id = @reply_id, updated_at = now();
+
+-- name: DeleteConvertReply :exec
+DELETE FROM convert_replies WHERE message_id = @message_id;
+
+-- name: DeleteConvertRepliesBefore :execrows
+DELETE FROM convert_replies WHERE updated_at < @updated_at;
@@ -0,0 +1,26 @@
+-- name: GetUserTimestampStyle :one
+SELECT timestamp_style FROM user_settings WHERE user_id = @user_id;
+
+-- name: SetUserTimestampStyle :exec
+INSERT INTO user_settings (user_id, timestamp_style) VALUES (@user_id, @timestamp_style) ON CONFLICT (user_id) DO UPDATE SET timestamp_style = @timestamp_style;
+
+-- name: GetGuildTimestampStyle :one
+SELECT timestamp_style FROM guild_settings WHERE guild_id = @guild_id;
+
+-- name: SetGuildTimestampStyle :exec
+INSERT INTO guild_settings (guild_id, timestamp_style) VALUES (@guild_id, @timestamp_style) ON CONFLICT (guild_id) DO UPDATE SET timestamp_style = @timestamp_style;
+
+-- name: GetChannelMode :one
+SELECT mode FROM channel_settings WHERE channel_id = @channel_id;
+
+-- name: SetChannelMode :exec
+INSERT INTO channel_settings (channel_id, guild_id, mode) VALUES (@channel_id, @guild_id, @mode) ON CONFLICT (channel_id) DO UPDATE SET mode = @mode;
+
+-- name: GetGuildCooldown :one
+SELECT cooldown_seconds FROM guild_settings WHERE guild_id = @guild_id;
+
+-- name: SetGuildCooldown :exec
+INSERT INTO guild_settings (guild_id, cooldown_seconds) VALUES (@guild_id, @cooldown_seconds) ON CONFLICT (guild_id) DO UPDATE SET cooldown_seconds = @cooldown_seconds;
+
+-- name: DeleteUserSettings :exec
+DELETE FROM user_settings WHERE user_id = @user_id;
@@ -0,0 +1,8 @@
+-- name: GetStats :one
+SELECT
+    (SELECT COUNT(*) FROM timezones) AS users,
+    (SELECT COUNT(*) FROM guild_timezones) AS guild_timezones,
+    (SELECT COUNT(*) FROM guild_timezone_defaults) AS guild_defaults,
+    (SELECT COUNT(*) FROM travels) AS travels,
+    (SELECT COUNT(*) FROM channel_settings) AS channel_settings,
+    (SELECT COUNT(*) FROM cooldowns) AS cooldowns;
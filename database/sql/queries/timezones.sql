@@ -2,4 +2,43 @@
 SELECT timezone FROM timezones WHERE user_id = @user_id;
 
 -- name: SetTimezone :exec
-INSERT INTO timezones (user_id, timezone) VALUES (@user_id, @timezone) ON CONFLICT (user_id) DO UPDATE SET timezone = @timezone;  
\ No newline at end of file
+INSERT INTO timezones (user_id, timezone) VALUES (@user_id, @timezone) ON CONFLICT (user_id) DO UPDATE SET timezone = @timezone;
+
+-- name: GetGuildTimezone :one
+SELECT timezone FROM guild_timezones WHERE guild_id = @guild_id AND user_id = @user_id;
+
+-- name: SetGuildTimezone :exec
+INSERT INTO guild_timezones (guild_id, user_id, timezone) VALUES (@guild_id, @user_id, @timezone) ON CONFLICT (guild_id, user_id) DO UPDATE SET timezone = @timezone;
+
+-- name: DeleteGuildTimezone :exec
+DELETE FROM guild_timezones WHERE guild_id = @guild_id AND user_id = @user_id;
+
+-- name: GetGuildDefaultTimezone :one
+SELECT timezone FROM guild_timezone_defaults WHERE guild_id = @guild_id;
+
+-- name: SetGuildDefaultTimezone :exec
+INSERT INTO guild_timezone_defaults (guild_id, timezone) VALUES (@guild_id, @timezone) ON CONFLICT (guild_id) DO UPDATE SET timezone = @timezone;
+
+-- name: DeleteGuildDefaultTimezone :exec
+DELETE FROM guild_timezone_defaults WHERE guild_id = @guild_id;
+
+-- name: GetTimezoneAt :one
+SELECT timezone FROM timezone_history WHERE user_id = @user_id AND changed_at <= @changed_at ORDER BY changed_at DESC, id DESC LIMIT 1;
+
+-- name: DeleteTimezone :exec
+DELETE FROM timezones WHERE user_id = @user_id;
+
+-- name: ListPopularTimezones :many
+SELECT timezone, COUNT(*) AS users FROM timezones GROUP BY timezone ORDER BY users DESC, timezone LIMIT @max_zones;
+
+-- name: ListTimezones :many
+SELECT user_id, timezone FROM timezones WHERE user_id > @after_user_id ORDER BY user_id LIMIT @max_rows;
+
+-- name: ListUserGuildTimezones :many
+SELECT guild_id, timezone FROM guild_timezones WHERE user_id = @user_id ORDER BY guild_id;
+
+-- name: ListTimezoneHistory :many
+SELECT timezone, changed_at FROM timezone_history WHERE user_id = @user_id ORDER BY changed_at, id;
+
+-- name: DeleteTimezoneHistory :exec
+DELETE FROM timezone_history WHERE user_id = @user_id;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id VARCHAR(20) PRIMARY KEY,
+    timestamp_style VARCHAR(16) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS guild_settings (
+    guild_id VARCHAR(20) PRIMARY KEY,
+    timestamp_style VARCHAR(16) NOT NULL DEFAULT ''
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS guild_settings;
+DROP TABLE IF EXISTS user_settings;
+-- +goose StatementEnd
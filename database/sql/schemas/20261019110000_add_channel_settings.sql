@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS channel_settings (
+    channel_id VARCHAR(20) PRIMARY KEY,
+    guild_id VARCHAR(20) NOT NULL,
+    mode VARCHAR(16) NOT NULL DEFAULT 'react-only'
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS channel_settings;
+-- +goose StatementEnd
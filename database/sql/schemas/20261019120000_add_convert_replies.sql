@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS convert_replies (
+    message_id VARCHAR(20) PRIMARY KEY,
+    reply_channel_id VARCHAR(20) NOT NULL,
+    reply_id VARCHAR(20) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS convert_replies;
+-- +goose StatementEnd
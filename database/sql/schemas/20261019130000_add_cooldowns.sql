@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS cooldowns (
+    key VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS cooldowns_expires_at_idx ON cooldowns (expires_at);
+
+ALTER TABLE guild_settings ADD COLUMN IF NOT EXISTS cooldown_seconds INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE guild_settings DROP COLUMN IF EXISTS cooldown_seconds;
+DROP TABLE IF EXISTS cooldowns;
+-- +goose StatementEnd
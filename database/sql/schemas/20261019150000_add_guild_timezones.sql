@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS guild_timezone_defaults (
+    guild_id VARCHAR(20) PRIMARY KEY,
+    timezone VARCHAR(32) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS guild_timezones (
+    guild_id VARCHAR(20) NOT NULL,
+    user_id VARCHAR(20) NOT NULL,
+    timezone VARCHAR(32) NOT NULL,
+    PRIMARY KEY (guild_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS guild_timezones;
+DROP TABLE IF EXISTS guild_timezone_defaults;
+-- +goose StatementEnd
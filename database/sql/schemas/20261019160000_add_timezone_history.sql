@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS timezone_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(20) NOT NULL,
+    timezone VARCHAR(32) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS timezone_history_user_id_changed_at_idx ON timezone_history (user_id, changed_at);
+
+-- When existing zones were set is unknown, let them cover every older message
+INSERT INTO timezone_history (user_id, timezone, changed_at)
+SELECT user_id, timezone, 'epoch' FROM timezones;
+
+CREATE TABLE IF NOT EXISTS travels (
+    user_id VARCHAR(20) PRIMARY KEY,
+    home_timezone VARCHAR(32) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS travels_expires_at_idx ON travels (expires_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_timezone_history() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO timezone_history (user_id, timezone) VALUES (NEW.user_id, NEW.timezone);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER timezones_record_history
+AFTER INSERT OR UPDATE OF timezone ON timezones
+FOR EACH ROW EXECUTE FUNCTION record_timezone_history();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS timezones_record_history ON timezones;
+DROP FUNCTION IF EXISTS record_timezone_history();
+DROP TABLE IF EXISTS travels;
+DROP TABLE IF EXISTS timezone_history;
+-- +goose StatementEnd
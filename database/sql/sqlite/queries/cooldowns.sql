@@ -0,0 +1,9 @@
+-- name: AcquireCooldown :execrows
+INSERT INTO cooldowns (key, expires_at) VALUES (sqlc.arg(key), unixepoch() + CAST(sqlc.arg(seconds) AS INTEGER))
+ON CONFLICT (key) DO UPDATE SET expires_at = excluded.expires_at WHERE cooldowns.expires_at <= unixepoch();
+
+-- name: ReleaseCooldown :exec
+DELETE FROM cooldowns WHERE key = ?;
+
+-- name: SweepCooldowns :execrows
+DELETE FROM cooldowns WHERE expires_at <= unixepoch();
@@ -0,0 +1,12 @@
+-- name: GetConvertReply :one
+SELECT reply_channel_id, reply_id FROM convert_replies WHERE message_id = ?;
+
+-- name: SetConvertReply :exec
+INSERT INTO convert_replies (message_id, reply_channel_id, reply_id, updated_at) VALUES (?, ?, ?, unixepoch())
+ON CONFLICT (message_id) DO UPDATE SET reply_channel_id = excluded.reply_channel_id, reply_id = excluded.reply_id, updated_at = excluded.updated_at;
+
+-- name: DeleteConvertReply :exec
+DELETE FROM convert_replies WHERE message_id = ?;
+
+-- name: DeleteConvertRepliesBefore :execrows
+DELETE FROM convert_replies WHERE updated_at < ?;
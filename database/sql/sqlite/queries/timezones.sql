@@ -0,0 +1,44 @@
+-- name: GetTimezone :one
+SELECT timezone FROM timezones WHERE user_id = ?;
+
+-- name: SetTimezone :exec
+INSERT INTO timezones (user_id, timezone) VALUES (?, ?) ON CONFLICT (user_id) DO UPDATE SET timezone = excluded.timezone;
+
+-- name: GetGuildTimezone :one
+SELECT timezone FROM guild_timezones WHERE guild_id = ? AND user_id = ?;
+
+-- name: SetGuildTimezone :exec
+INSERT INTO guild_timezones (guild_id, user_id, timezone) VALUES (?, ?, ?) ON CONFLICT (guild_id, user_id) DO UPDATE SET timezone = excluded.timezone;
+
+-- name: DeleteGuildTimezone :exec
+DELETE FROM guild_timezones WHERE guild_id = ? AND user_id = ?;
+
+-- name: GetGuildDefaultTimezone :one
+SELECT timezone FROM guild_timezone_defaults WHERE guild_id = ?;
+
+-- name: SetGuildDefaultTimezone :exec
+INSERT INTO guild_timezone_defaults (guild_id, timezone) VALUES (?, ?) ON CONFLICT (guild_id) DO UPDATE SET timezone = excluded.timezone;
+
+-- name: DeleteGuildDefaultTimezone :exec
+DELETE FROM guild_timezone_defaults WHERE guild_id = ?;
+
+-- name: GetTimezoneAt :one
+SELECT timezone FROM timezone_history WHERE user_id = ? AND changed_at <= ? ORDER BY changed_at DESC, id DESC LIMIT 1;
+
+-- name: DeleteTimezone :exec
+DELETE FROM timezones WHERE user_id = ?;
+
+-- name: ListPopularTimezones :many
+SELECT timezone, COUNT(*) AS users FROM timezones GROUP BY timezone ORDER BY users DESC, timezone LIMIT sqlc.arg(max_zones);
+
+-- name: ListTimezones :many
+SELECT user_id, timezone FROM timezones WHERE user_id > sqlc.arg(after_user_id) ORDER BY user_id LIMIT sqlc.arg(max_rows);
+
+-- name: ListUserGuildTimezones :many
+SELECT guild_id, timezone FROM guild_timezones WHERE user_id = ? ORDER BY guild_id;
+
+-- name: ListTimezoneHistory :many
+SELECT timezone, changed_at FROM timezone_history WHERE user_id = ? ORDER BY changed_at, id;
+
+-- name: DeleteTimezoneHistory :exec
+DELETE FROM timezone_history WHERE user_id = ?;
@@ -0,0 +1,14 @@
+-- name: GetTravel :one
+SELECT user_id, home_timezone, expires_at FROM travels WHERE user_id = ?;
+
+-- name: SetTravel :exec
+INSERT INTO travels (user_id, home_timezone, expires_at) VALUES (?, ?, ?) ON CONFLICT (user_id) DO UPDATE SET expires_at = excluded.expires_at;
+
+-- name: DeleteTravel :exec
+DELETE FROM travels WHERE user_id = ?;
+
+-- name: ListExpiredTravels :many
+SELECT user_id, home_timezone, expires_at FROM travels WHERE expires_at <= unixepoch() ORDER BY expires_at;
+
+-- name: DeleteExpiredTravel :execrows
+DELETE FROM travels WHERE user_id = ? AND expires_at <= unixepoch();
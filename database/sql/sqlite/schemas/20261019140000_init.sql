@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS timezones (
+    user_id TEXT PRIMARY KEY,
+    timezone TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id TEXT PRIMARY KEY,
+    timestamp_style TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS guild_settings (
+    guild_id TEXT PRIMARY KEY,
+    timestamp_style TEXT NOT NULL DEFAULT '',
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS channel_settings (
+    channel_id TEXT PRIMARY KEY,
+    guild_id TEXT NOT NULL,
+    mode TEXT NOT NULL DEFAULT 'react-only'
+);
+
+CREATE TABLE IF NOT EXISTS convert_replies (
+    message_id TEXT PRIMARY KEY,
+    reply_channel_id TEXT NOT NULL,
+    reply_id TEXT NOT NULL,
+    updated_at INTEGER NOT NULL DEFAULT (unixepoch())
+);
+
+CREATE TABLE IF NOT EXISTS cooldowns (
+    key TEXT PRIMARY KEY,
+    expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS cooldowns_expires_at_idx ON cooldowns (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS cooldowns;
+DROP TABLE IF EXISTS convert_replies;
+DROP TABLE IF EXISTS channel_settings;
+DROP TABLE IF EXISTS guild_settings;
+DROP TABLE IF EXISTS user_settings;
+DROP TABLE IF EXISTS timezones;
+-- +goose StatementEnd
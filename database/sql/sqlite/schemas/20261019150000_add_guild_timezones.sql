@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS guild_timezone_defaults (
+    guild_id TEXT PRIMARY KEY,
+    timezone TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS guild_timezones (
+    guild_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    timezone TEXT NOT NULL,
+    PRIMARY KEY (guild_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS guild_timezones;
+DROP TABLE IF EXISTS guild_timezone_defaults;
+-- +goose StatementEnd
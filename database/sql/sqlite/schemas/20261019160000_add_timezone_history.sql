@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS timezone_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    timezone TEXT NOT NULL,
+    changed_at INTEGER NOT NULL DEFAULT (unixepoch())
+);
+
+CREATE INDEX IF NOT EXISTS timezone_history_user_id_changed_at_idx ON timezone_history (user_id, changed_at);
+
+-- When existing zones were set is unknown, let them cover every older message
+INSERT INTO timezone_history (user_id, timezone, changed_at)
+SELECT user_id, timezone, 0 FROM timezones;
+
+CREATE TABLE IF NOT EXISTS travels (
+    user_id TEXT PRIMARY KEY,
+    home_timezone TEXT NOT NULL,
+    expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS travels_expires_at_idx ON travels (expires_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS timezones_record_history_insert
+AFTER INSERT ON timezones
+BEGIN
+    INSERT INTO timezone_history (user_id, timezone) VALUES (NEW.user_id, NEW.timezone);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS timezones_record_history_update
+AFTER UPDATE OF timezone ON timezones
+BEGIN
+    INSERT INTO timezone_history (user_id, timezone) VALUES (NEW.user_id, NEW.timezone);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS timezones_record_history_update;
+DROP TRIGGER IF EXISTS timezones_record_history_insert;
+DROP TABLE IF EXISTS travels;
+DROP TABLE IF EXISTS timezone_history;
+-- +goose StatementEnd
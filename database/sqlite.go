@@ -0,0 +1,320 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database/sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore is a Store backed by an embedded SQLite database file
+type SQLiteStore struct {
+	sqliteQueries
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a Store using db, which must be opened with the "sqlite" driver
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{
+		sqliteQueries: sqliteQueries{q: sqlite.New(tracedDB{db})},
+		db:            db,
+	}
+}
+
+// sqlitePathEscaper escapes the characters that end or escape the path of a SQLite URI
+var sqlitePathEscaper = strings.NewReplacer("%", "%25", "?", "%3F", "#", "%23")
+
+// sqliteDSN returns the URI opening path with the connection options every store uses
+func sqliteDSN(path string) string {
+	u := url.URL{
+		Scheme:   "file",
+		Opaque:   sqlitePathEscaper.Replace(path),
+		RawQuery: "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate",
+	}
+	return u.String()
+}
+
+// openSQLite opens the SQLite database at path, creating it if needed
+func openSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, and every connection to ":memory:" is a separate database
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// InTx runs fn in a transaction, committing it if fn returns nil and rolling it back otherwise
+func (s *SQLiteStore) InTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(sqliteQueries{q: sqlite.New(tracedDB{tx})}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Ping checks the database file can be used
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() {
+	s.db.Close()
+}
+
+// sqliteQueries adapts the sqlc-generated SQLite queries to Querier
+type sqliteQueries struct {
+	q *sqlite.Queries
+}
+
+var _ Querier = sqliteQueries{}
+
+// sqliteErr reports missing rows as ErrNoRows, like the Postgres store
+func sqliteErr(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoRows
+	}
+	return err
+}
+
+// sqliteTravel converts the unix times SQLite stores into a Travel
+func sqliteTravel(travel sqlite.Travel) Travel {
+	return Travel{
+		UserID:       travel.UserID,
+		HomeTimezone: travel.HomeTimezone,
+		ExpiresAt:    time.Unix(travel.ExpiresAt, 0),
+	}
+}
+
+func (s sqliteQueries) AcquireCooldown(ctx context.Context, arg AcquireCooldownParams) (int64, error) {
+	rows, err := s.q.AcquireCooldown(ctx, sqlite.AcquireCooldownParams{
+		Key:     arg.Key,
+		Seconds: int64(math.Ceil(arg.Seconds)),
+	})
+	return rows, sqliteErr(err)
+}
+
+func (s sqliteQueries) DeleteConvertRepliesBefore(ctx context.Context, updatedAt time.Time) (int64, error) {
+	rows, err := s.q.DeleteConvertRepliesBefore(ctx, updatedAt.Unix())
+	return rows, sqliteErr(err)
+}
+
+func (s sqliteQueries) DeleteConvertReply(ctx context.Context, messageID string) error {
+	return sqliteErr(s.q.DeleteConvertReply(ctx, messageID))
+}
+
+func (s sqliteQueries) DeleteExpiredTravel(ctx context.Context, userID string) (int64, error) {
+	rows, err := s.q.DeleteExpiredTravel(ctx, userID)
+	return rows, sqliteErr(err)
+}
+
+func (s sqliteQueries) DeleteGuildDefaultTimezone(ctx context.Context, guildID string) error {
+	return sqliteErr(s.q.DeleteGuildDefaultTimezone(ctx, guildID))
+}
+
+func (s sqliteQueries) DeleteGuildTimezone(ctx context.Context, arg DeleteGuildTimezoneParams) error {
+	return sqliteErr(s.q.DeleteGuildTimezone(ctx, sqlite.DeleteGuildTimezoneParams(arg)))
+}
+
+func (s sqliteQueries) DeleteTimezone(ctx context.Context, userID string) error {
+	return sqliteErr(s.q.DeleteTimezone(ctx, userID))
+}
+
+func (s sqliteQueries) DeleteTimezoneHistory(ctx context.Context, userID string) error {
+	return sqliteErr(s.q.DeleteTimezoneHistory(ctx, userID))
+}
+
+func (s sqliteQueries) DeleteTravel(ctx context.Context, userID string) error {
+	return sqliteErr(s.q.DeleteTravel(ctx, userID))
+}
+
+func (s sqliteQueries) DeleteUserSettings(ctx context.Context, userID string) error {
+	return sqliteErr(s.q.DeleteUserSettings(ctx, userID))
+}
+
+func (s sqliteQueries) GetChannelMode(ctx context.Context, channelID string) (string, error) {
+	mode, err := s.q.GetChannelMode(ctx, channelID)
+	return mode, sqliteErr(err)
+}
+
+func (s sqliteQueries) GetConvertReply(ctx context.Context, messageID string) (GetConvertReplyRow, error) {
+	row, err := s.q.GetConvertReply(ctx, messageID)
+	return GetConvertReplyRow(row), sqliteErr(err)
+}
+
+func (s sqliteQueries) GetGuildCooldown(ctx context.Context, guildID string) (int32, error) {
+	seconds, err := s.q.GetGuildCooldown(ctx, guildID)
+	return int32(seconds), sqliteErr(err)
+}
+
+func (s sqliteQueries) GetGuildDefaultTimezone(ctx context.Context, guildID string) (string, error) {
+	timezone, err := s.q.GetGuildDefaultTimezone(ctx, guildID)
+	return timezone, sqliteErr(err)
+}
+
+func (s sqliteQueries) GetGuildTimestampStyle(ctx context.Context, guildID string) (string, error) {
+	style, err := s.q.GetGuildTimestampStyle(ctx, guildID)
+	return style, sqliteErr(err)
+}
+
+func (s sqliteQueries) GetGuildTimezone(ctx context.Context, arg GetGuildTimezoneParams) (string, error) {
+	timezone, err := s.q.GetGuildTimezone(ctx, sqlite.GetGuildTimezoneParams(arg))
+	return timezone, sqliteErr(err)
+}
+
+func (s sqliteQueries) GetStats(ctx context.Context) (GetStatsRow, error) {
+	stats, err := s.q.GetStats(ctx)
+	return GetStatsRow(stats), sqliteErr(err)
+}
+
+func (s sqliteQueries) GetTimezone(ctx context.Context, userID string) (string, error) {
+	timezone, err := s.q.GetTimezone(ctx, userID)
+	return timezone, sqliteErr(err)
+}
+
+func (s sqliteQueries) GetTimezoneAt(ctx context.Context, arg GetTimezoneAtParams) (string, error) {
+	timezone, err := s.q.GetTimezoneAt(ctx, sqlite.GetTimezoneAtParams{
+		UserID:    arg.UserID,
+		ChangedAt: arg.ChangedAt.Unix(),
+	})
+	return timezone, sqliteErr(err)
+}
+
+func (s sqliteQueries) GetTravel(ctx context.Context, userID string) (Travel, error) {
+	travel, err := s.q.GetTravel(ctx, userID)
+	return sqliteTravel(travel), sqliteErr(err)
+}
+
+func (s sqliteQueries) GetUserTimestampStyle(ctx context.Context, userID string) (string, error) {
+	style, err := s.q.GetUserTimestampStyle(ctx, userID)
+	return style, sqliteErr(err)
+}
+
+func (s sqliteQueries) ListExpiredTravels(ctx context.Context) ([]Travel, error) {
+	rows, err := s.q.ListExpiredTravels(ctx)
+	if err != nil {
+		return nil, sqliteErr(err)
+	}
+	travels := make([]Travel, len(rows))
+	for i, row := range rows {
+		travels[i] = sqliteTravel(row)
+	}
+	return travels, nil
+}
+
+func (s sqliteQueries) ListPopularTimezones(ctx context.Context, maxZones int32) ([]ListPopularTimezonesRow, error) {
+	rows, err := s.q.ListPopularTimezones(ctx, int64(maxZones))
+	if err != nil {
+		return nil, sqliteErr(err)
+	}
+	zones := make([]ListPopularTimezonesRow, len(rows))
+	for i, row := range rows {
+		zones[i] = ListPopularTimezonesRow(row)
+	}
+	return zones, nil
+}
+
+func (s sqliteQueries) ListTimezoneHistory(ctx context.Context, userID string) ([]ListTimezoneHistoryRow, error) {
+	rows, err := s.q.ListTimezoneHistory(ctx, userID)
+	if err != nil {
+		return nil, sqliteErr(err)
+	}
+	history := make([]ListTimezoneHistoryRow, len(rows))
+	for i, row := range rows {
+		history[i] = ListTimezoneHistoryRow{Timezone: row.Timezone, ChangedAt: time.Unix(row.ChangedAt, 0)}
+	}
+	return history, nil
+}
+
+func (s sqliteQueries) ListTimezones(ctx context.Context, arg ListTimezonesParams) ([]Timezone, error) {
+	rows, err := s.q.ListTimezones(ctx, sqlite.ListTimezonesParams{
+		AfterUserID: arg.AfterUserID,
+		MaxRows:     int64(arg.MaxRows),
+	})
+	if err != nil {
+		return nil, sqliteErr(err)
+	}
+	timezones := make([]Timezone, len(rows))
+	for i, row := range rows {
+		timezones[i] = Timezone(row)
+	}
+	return timezones, nil
+}
+
+func (s sqliteQueries) ListUserGuildTimezones(ctx context.Context, userID string) ([]ListUserGuildTimezonesRow, error) {
+	rows, err := s.q.ListUserGuildTimezones(ctx, userID)
+	if err != nil {
+		return nil, sqliteErr(err)
+	}
+	timezones := make([]ListUserGuildTimezonesRow, len(rows))
+	for i, row := range rows {
+		timezones[i] = ListUserGuildTimezonesRow(row)
+	}
+	return timezones, nil
+}
+
+func (s sqliteQueries) ReleaseCooldown(ctx context.Context, key string) error {
+	return sqliteErr(s.q.ReleaseCooldown(ctx, key))
+}
+
+func (s sqliteQueries) SetChannelMode(ctx context.Context, arg SetChannelModeParams) error {
+	return sqliteErr(s.q.SetChannelMode(ctx, sqlite.SetChannelModeParams(arg)))
+}
+
+func (s sqliteQueries) SetConvertReply(ctx context.Context, arg SetConvertReplyParams) error {
+	return sqliteErr(s.q.SetConvertReply(ctx, sqlite.SetConvertReplyParams(arg)))
+}
+
+func (s sqliteQueries) SetGuildCooldown(ctx context.Context, arg SetGuildCooldownParams) error {
+	return sqliteErr(s.q.SetGuildCooldown(ctx, sqlite.SetGuildCooldownParams{
+		GuildID:         arg.GuildID,
+		CooldownSeconds: int64(arg.CooldownSeconds),
+	}))
+}
+
+func (s sqliteQueries) SetGuildDefaultTimezone(ctx context.Context, arg SetGuildDefaultTimezoneParams) error {
+	return sqliteErr(s.q.SetGuildDefaultTimezone(ctx, sqlite.SetGuildDefaultTimezoneParams(arg)))
+}
+
+func (s sqliteQueries) SetGuildTimestampStyle(ctx context.Context, arg SetGuildTimestampStyleParams) error {
+	return sqliteErr(s.q.SetGuildTimestampStyle(ctx, sqlite.SetGuildTimestampStyleParams(arg)))
+}
+
+func (s sqliteQueries) SetGuildTimezone(ctx context.Context, arg SetGuildTimezoneParams) error {
+	return sqliteErr(s.q.SetGuildTimezone(ctx, sqlite.SetGuildTimezoneParams(arg)))
+}
+
+func (s sqliteQueries) SetTimezone(ctx context.Context, arg SetTimezoneParams) error {
+	return sqliteErr(s.q.SetTimezone(ctx, sqlite.SetTimezoneParams(arg)))
+}
+
+func (s sqliteQueries) SetTravel(ctx context.Context, arg SetTravelParams) error {
+	return sqliteErr(s.q.SetTravel(ctx, sqlite.SetTravelParams{
+		UserID:       arg.UserID,
+		HomeTimezone: arg.HomeTimezone,
+		ExpiresAt:    arg.ExpiresAt.Unix(),
+	}))
+}
+
+func (s sqliteQueries) SetUserTimestampStyle(ctx context.Context, arg SetUserTimestampStyleParams) error {
+	return sqliteErr(s.q.SetUserTimestampStyle(ctx, sqlite.SetUserTimestampStyleParams(arg)))
+}
+
+func (s sqliteQueries) SweepCooldowns(ctx context.Context) (int64, error) {
+	rows, err := s.q.SweepCooldowns(ctx)
+	return rows, sqliteErr(err)
+}
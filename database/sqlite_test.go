@@ -0,0 +1,387 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestSQLiteStore creates a migrated SQLite store in a temporary directory
+func newTestSQLiteStore(t *testing.T) Store {
+	t.Helper()
+
+	cfg := DatabaseConfig{
+		Driver: DriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "test.db"),
+	}
+	if err := RunMigrations(cfg); err != nil {
+		t.Fatalf("RunMigrations() unexpected error: %v", err)
+	}
+
+	store, err := MakeDatabase(cfg)
+	if err != nil {
+		t.Fatalf("MakeDatabase() unexpected error: %v", err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func TestSQLiteStore_Timezones(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	if _, err := store.GetTimezone(ctx, "1"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetTimezone() error = %v, want ErrNoRows", err)
+	}
+
+	for _, timezone := range []string{"Europe/London", "Asia/Tokyo"} {
+		if err := store.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: timezone}); err != nil {
+			t.Fatalf("SetTimezone() unexpected error: %v", err)
+		}
+		if got, err := store.GetTimezone(ctx, "1"); err != nil || got != timezone {
+			t.Errorf("GetTimezone() = %q, %v, want %q", got, err, timezone)
+		}
+	}
+}
+
+func TestSQLiteStore_DeleteTimezone(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	if err := store.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: "Europe/London"}); err != nil {
+		t.Fatalf("SetTimezone() unexpected error: %v", err)
+	}
+	if err := store.DeleteTimezone(ctx, "1"); err != nil {
+		t.Fatalf("DeleteTimezone() unexpected error: %v", err)
+	}
+	if _, err := store.GetTimezone(ctx, "1"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetTimezone() error = %v after DeleteTimezone, want ErrNoRows", err)
+	}
+}
+
+func TestDeleteUserData(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	for _, userID := range []string{"1", "2"} {
+		err := store.InTx(ctx, func(q Querier) error {
+			if err := q.SetTimezone(ctx, SetTimezoneParams{UserID: userID, Timezone: "Europe/London"}); err != nil {
+				return err
+			}
+			if err := q.SetGuildTimezone(ctx, SetGuildTimezoneParams{GuildID: "10", UserID: userID, Timezone: "Asia/Tokyo"}); err != nil {
+				return err
+			}
+			if err := q.SetUserTimestampStyle(ctx, SetUserTimestampStyleParams{UserID: userID, TimestampStyle: "F"}); err != nil {
+				return err
+			}
+			return q.SetTravel(ctx, SetTravelParams{UserID: userID, HomeTimezone: "Europe/London", ExpiresAt: time.Now().Add(time.Hour)})
+		})
+		if err != nil {
+			t.Fatalf("saving user data unexpected error: %v", err)
+		}
+	}
+
+	if err := store.InTx(ctx, func(q Querier) error { return DeleteUserData(ctx, q, "1") }); err != nil {
+		t.Fatalf("DeleteUserData() unexpected error: %v", err)
+	}
+
+	if _, err := store.GetTimezone(ctx, "1"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetTimezone() error = %v after DeleteUserData, want ErrNoRows", err)
+	}
+	if _, err := store.GetTravel(ctx, "1"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetTravel() error = %v after DeleteUserData, want ErrNoRows", err)
+	}
+	if _, err := store.GetUserTimestampStyle(ctx, "1"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetUserTimestampStyle() error = %v after DeleteUserData, want ErrNoRows", err)
+	}
+	if rows, err := store.ListUserGuildTimezones(ctx, "1"); err != nil || len(rows) != 0 {
+		t.Errorf("ListUserGuildTimezones() = %v, %v after DeleteUserData, want none", rows, err)
+	}
+	if rows, err := store.ListTimezoneHistory(ctx, "1"); err != nil || len(rows) != 0 {
+		t.Errorf("ListTimezoneHistory() = %v, %v after DeleteUserData, want none", rows, err)
+	}
+
+	if _, err := store.GetTimezone(ctx, "2"); err != nil {
+		t.Errorf("GetTimezone() error = %v for another user, want it kept", err)
+	}
+}
+
+func TestSQLiteStore_Stats(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	for userID, timezone := range map[string]string{"1": "Asia/Tokyo", "2": "Europe/London", "3": "Asia/Tokyo"} {
+		if err := store.SetTimezone(ctx, SetTimezoneParams{UserID: userID, Timezone: timezone}); err != nil {
+			t.Fatalf("SetTimezone() unexpected error: %v", err)
+		}
+	}
+	if err := store.SetGuildTimezone(ctx, SetGuildTimezoneParams{GuildID: "10", UserID: "1", Timezone: "UTC"}); err != nil {
+		t.Fatalf("SetGuildTimezone() unexpected error: %v", err)
+	}
+
+	stats, err := store.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats() unexpected error: %v", err)
+	}
+	if want := (GetStatsRow{Users: 3, GuildTimezones: 1}); stats != want {
+		t.Errorf("GetStats() = %+v, want %+v", stats, want)
+	}
+
+	zones, err := store.ListPopularTimezones(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListPopularTimezones() unexpected error: %v", err)
+	}
+	if len(zones) != 1 || zones[0] != (ListPopularTimezonesRow{Timezone: "Asia/Tokyo", Users: 2}) {
+		t.Errorf("ListPopularTimezones() = %+v, want Asia/Tokyo with 2 users", zones)
+	}
+}
+
+func TestSQLiteStore_Ping(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	if err := store.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() unexpected error: %v", err)
+	}
+
+	store.Close()
+	if err := store.Ping(context.Background()); err == nil {
+		t.Error("Ping() = nil on a closed store")
+	}
+}
+
+func TestSQLiteStore_PathEscaping(t *testing.T) {
+	ctx := context.Background()
+	// Unescaped, the ? would start the options and let the path add its own pragmas
+	dir := t.TempDir()
+	cfg := DatabaseConfig{
+		Driver: DriverSQLite,
+		Path:   filepath.Join(dir, "bot?_pragma=query_only(1)#%41.db"),
+	}
+	if err := RunMigrations(cfg); err != nil {
+		t.Fatalf("RunMigrations() unexpected error: %v", err)
+	}
+	store, err := MakeDatabase(cfg)
+	if err != nil {
+		t.Fatalf("MakeDatabase() unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: "Europe/London"}); err != nil {
+		t.Fatalf("SetTimezone() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(cfg.Path); err != nil {
+		t.Errorf("database not created at its exact path: %v", err)
+	}
+}
+
+func TestSQLiteStore_GuildTimezones(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	member := GetGuildTimezoneParams{GuildID: "10", UserID: "1"}
+	if err := store.SetGuildTimezone(ctx, SetGuildTimezoneParams{GuildID: "10", UserID: "1", Timezone: "Asia/Tokyo"}); err != nil {
+		t.Fatalf("SetGuildTimezone() unexpected error: %v", err)
+	}
+	if got, err := store.GetGuildTimezone(ctx, member); err != nil || got != "Asia/Tokyo" {
+		t.Errorf("GetGuildTimezone() = %q, %v, want Asia/Tokyo", got, err)
+	}
+	if _, err := store.GetGuildTimezone(ctx, GetGuildTimezoneParams{GuildID: "11", UserID: "1"}); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetGuildTimezone() in another guild error = %v, want ErrNoRows", err)
+	}
+	if err := store.DeleteGuildTimezone(ctx, DeleteGuildTimezoneParams(member)); err != nil {
+		t.Fatalf("DeleteGuildTimezone() unexpected error: %v", err)
+	}
+	if _, err := store.GetGuildTimezone(ctx, member); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetGuildTimezone() after delete error = %v, want ErrNoRows", err)
+	}
+
+	if err := store.SetGuildDefaultTimezone(ctx, SetGuildDefaultTimezoneParams{GuildID: "10", Timezone: "Europe/Paris"}); err != nil {
+		t.Fatalf("SetGuildDefaultTimezone() unexpected error: %v", err)
+	}
+	if got, err := store.GetGuildDefaultTimezone(ctx, "10"); err != nil || got != "Europe/Paris" {
+		t.Errorf("GetGuildDefaultTimezone() = %q, %v, want Europe/Paris", got, err)
+	}
+	if err := store.DeleteGuildDefaultTimezone(ctx, "10"); err != nil {
+		t.Fatalf("DeleteGuildDefaultTimezone() unexpected error: %v", err)
+	}
+	if _, err := store.GetGuildDefaultTimezone(ctx, "10"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetGuildDefaultTimezone() after delete error = %v, want ErrNoRows", err)
+	}
+}
+
+func TestSQLiteStore_TimezoneHistory(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	before := time.Now().Add(-time.Hour)
+	after := time.Now().Add(time.Hour)
+
+	for _, timezone := range []string{"Europe/London", "Asia/Tokyo"} {
+		if err := store.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: timezone}); err != nil {
+			t.Fatalf("SetTimezone() unexpected error: %v", err)
+		}
+	}
+
+	if _, err := store.GetTimezoneAt(ctx, GetTimezoneAtParams{UserID: "1", ChangedAt: before}); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetTimezoneAt() before any change error = %v, want ErrNoRows", err)
+	}
+	if got, err := store.GetTimezoneAt(ctx, GetTimezoneAtParams{UserID: "1", ChangedAt: after}); err != nil || got != "Asia/Tokyo" {
+		t.Errorf("GetTimezoneAt() = %q, %v, want the latest change Asia/Tokyo", got, err)
+	}
+}
+
+func TestSQLiteStore_Travels(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := store.SetTravel(ctx, SetTravelParams{UserID: "1", HomeTimezone: "Europe/London", ExpiresAt: expires}); err != nil {
+		t.Fatalf("SetTravel() unexpected error: %v", err)
+	}
+	// Extending a trip keeps the zone to return to
+	if err := store.SetTravel(ctx, SetTravelParams{UserID: "1", HomeTimezone: "Asia/Tokyo", ExpiresAt: expires.Add(time.Hour)}); err != nil {
+		t.Fatalf("SetTravel() unexpected error: %v", err)
+	}
+	travel, err := store.GetTravel(ctx, "1")
+	if err != nil || travel.HomeTimezone != "Europe/London" || !travel.ExpiresAt.Equal(expires.Add(time.Hour)) {
+		t.Errorf("GetTravel() = %+v, %v, want home Europe/London until %v", travel, err, expires.Add(time.Hour))
+	}
+
+	if rows, err := store.DeleteExpiredTravel(ctx, "1"); err != nil || rows != 0 {
+		t.Errorf("DeleteExpiredTravel() = %d, %v before expiry, want 0", rows, err)
+	}
+
+	if err := store.SetTravel(ctx, SetTravelParams{UserID: "2", HomeTimezone: "UTC", ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatalf("SetTravel() unexpected error: %v", err)
+	}
+	expired, err := store.ListExpiredTravels(ctx)
+	if err != nil || len(expired) != 1 || expired[0].UserID != "2" {
+		t.Fatalf("ListExpiredTravels() = %+v, %v, want only user 2", expired, err)
+	}
+	if rows, err := store.DeleteExpiredTravel(ctx, "2"); err != nil || rows != 1 {
+		t.Errorf("DeleteExpiredTravel() = %d, %v after expiry, want 1", rows, err)
+	}
+}
+
+func TestSQLiteStore_ConvertReplies(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	if err := store.SetConvertReply(ctx, SetConvertReplyParams{MessageID: "1", ReplyChannelID: "20", ReplyID: "2"}); err != nil {
+		t.Fatalf("SetConvertReply() unexpected error: %v", err)
+	}
+
+	if n, err := store.DeleteConvertRepliesBefore(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("DeleteConvertRepliesBefore() = %d, %v for a recent reply, want it kept", n, err)
+	}
+	if n, err := store.DeleteConvertRepliesBefore(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("DeleteConvertRepliesBefore() = %d, %v for an old reply, want it removed", n, err)
+	}
+	if _, err := store.GetConvertReply(ctx, "1"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetConvertReply() error = %v after removing old replies, want ErrNoRows", err)
+	}
+}
+
+func TestSQLiteStore_Cooldowns(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	acquire := func(seconds float64) int64 {
+		t.Helper()
+		rows, err := store.AcquireCooldown(ctx, AcquireCooldownParams{Key: "1", Seconds: seconds})
+		if err != nil {
+			t.Fatalf("AcquireCooldown() unexpected error: %v", err)
+		}
+		return rows
+	}
+
+	if acquire(60) != 1 {
+		t.Errorf("AcquireCooldown() on a fresh key acquired nothing")
+	}
+	if acquire(60) != 0 {
+		t.Errorf("AcquireCooldown() during cooldown acquired it again")
+	}
+
+	if err := store.ReleaseCooldown(ctx, "1"); err != nil {
+		t.Fatalf("ReleaseCooldown() unexpected error: %v", err)
+	}
+	if acquire(0) != 1 {
+		t.Errorf("AcquireCooldown() after release acquired nothing")
+	}
+
+	if rows, err := store.SweepCooldowns(ctx); err != nil || rows != 1 {
+		t.Errorf("SweepCooldowns() = %d, %v, want 1 expired cooldown", rows, err)
+	}
+}
+
+func TestSQLiteStore_InTx(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	failure := errors.New("rollback")
+	err := store.InTx(ctx, func(q Querier) error {
+		if err := q.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: "UTC"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("InTx() error = %v, want %v", err, failure)
+	}
+	if _, err := store.GetTimezone(ctx, "1"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetTimezone() after rollback error = %v, want ErrNoRows", err)
+	}
+
+	err = store.InTx(ctx, func(q Querier) error {
+		return q.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: "UTC"})
+	})
+	if err != nil {
+		t.Fatalf("InTx() unexpected error: %v", err)
+	}
+	if got, err := store.GetTimezone(ctx, "1"); err != nil || got != "UTC" {
+		t.Errorf("GetTimezone() after commit = %q, %v, want UTC", got, err)
+	}
+}
+
+func TestCachedStore_InTxInvalidates(t *testing.T) {
+	ctx := context.Background()
+	store := NewCachedStore(newTestSQLiteStore(t), time.Minute, 10)
+
+	store.GetTimezone(ctx, "1")
+	store.InTx(ctx, func(q Querier) error {
+		return q.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: "UTC"})
+	})
+
+	if got, err := store.GetTimezone(ctx, "1"); err != nil || got != "UTC" {
+		t.Errorf("GetTimezone() after InTx() = %q, %v, want UTC", got, err)
+	}
+}
+
+func TestObservedStore(t *testing.T) {
+	ctx := context.Background()
+
+	var observed []string
+	store := NewObservedStore(newTestSQLiteStore(t), func(query string, took time.Duration, err error) {
+		if took < 0 {
+			t.Errorf("%s took %v", query, took)
+		}
+		observed = append(observed, query)
+	})
+
+	if _, err := store.GetTimezone(ctx, "1"); !errors.Is(err, ErrNoRows) {
+		t.Fatalf("GetTimezone() error = %v, want ErrNoRows", err)
+	}
+	err := store.InTx(ctx, func(q Querier) error {
+		return q.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: "Europe/London"})
+	})
+	if err != nil {
+		t.Fatalf("InTx() unexpected error: %v", err)
+	}
+
+	want := []string{"GetTimezone", "SetTimezone"}
+	if len(observed) != len(want) || observed[0] != want[0] || observed[1] != want[1] {
+		t.Errorf("observed queries = %v, want %v", observed, want)
+	}
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoRows is returned by every Store when a query finds nothing
+var ErrNoRows = pgx.ErrNoRows
+
+// Store is a database backend the bot can run against
+type Store interface {
+	Querier
+
+	// InTx runs fn in a transaction, committing it if fn returns nil and rolling it back otherwise
+	InTx(ctx context.Context, fn func(Querier) error) error
+	// Ping checks that the database can be reached
+	Ping(ctx context.Context) error
+	// Close releases the connections held by the store
+	Close()
+}
+
+// DeleteUserData removes every row stored about userID. It should run in a transaction
+// so a failure leaves everything in place.
+func DeleteUserData(ctx context.Context, q Querier, userID string) error {
+	// Guild timezones are removed one by one so a CachedStore drops each of them
+	guildTimezones, err := q.ListUserGuildTimezones(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, row := range guildTimezones {
+		if err := q.DeleteGuildTimezone(ctx, DeleteGuildTimezoneParams{GuildID: row.GuildID, UserID: userID}); err != nil {
+			return err
+		}
+	}
+
+	// The travel goes first, a trip left behind would bring the timezone back once it ends
+	deletes := []func(context.Context, string) error{
+		q.DeleteTravel,
+		q.DeleteTimezone,
+		q.DeleteUserSettings,
+		q.DeleteTimezoneHistory,
+	}
+	for _, del := range deletes {
+		if err := del(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
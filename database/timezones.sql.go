@@ -7,8 +7,77 @@ package database
 
 import (
 	"context"
+	"time"
 )
 
+const deleteGuildDefaultTimezone = `-- name: DeleteGuildDefaultTimezone :exec
+DELETE FROM guild_timezone_defaults WHERE guild_id = $1
+`
+
+func (q *Queries) DeleteGuildDefaultTimezone(ctx context.Context, guildID string) error {
+	_, err := q.db.Exec(ctx, deleteGuildDefaultTimezone, guildID)
+	return err
+}
+
+const deleteGuildTimezone = `-- name: DeleteGuildTimezone :exec
+DELETE FROM guild_timezones WHERE guild_id = $1 AND user_id = $2
+`
+
+type DeleteGuildTimezoneParams struct {
+	GuildID string
+	UserID  string
+}
+
+func (q *Queries) DeleteGuildTimezone(ctx context.Context, arg DeleteGuildTimezoneParams) error {
+	_, err := q.db.Exec(ctx, deleteGuildTimezone, arg.GuildID, arg.UserID)
+	return err
+}
+
+const deleteTimezone = `-- name: DeleteTimezone :exec
+DELETE FROM timezones WHERE user_id = $1
+`
+
+func (q *Queries) DeleteTimezone(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deleteTimezone, userID)
+	return err
+}
+
+const deleteTimezoneHistory = `-- name: DeleteTimezoneHistory :exec
+DELETE FROM timezone_history WHERE user_id = $1
+`
+
+func (q *Queries) DeleteTimezoneHistory(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, deleteTimezoneHistory, userID)
+	return err
+}
+
+const getGuildDefaultTimezone = `-- name: GetGuildDefaultTimezone :one
+SELECT timezone FROM guild_timezone_defaults WHERE guild_id = $1
+`
+
+func (q *Queries) GetGuildDefaultTimezone(ctx context.Context, guildID string) (string, error) {
+	row := q.db.QueryRow(ctx, getGuildDefaultTimezone, guildID)
+	var timezone string
+	err := row.Scan(&timezone)
+	return timezone, err
+}
+
+const getGuildTimezone = `-- name: GetGuildTimezone :one
+SELECT timezone FROM guild_timezones WHERE guild_id = $1 AND user_id = $2
+`
+
+type GetGuildTimezoneParams struct {
+	GuildID string
+	UserID  string
+}
+
+func (q *Queries) GetGuildTimezone(ctx context.Context, arg GetGuildTimezoneParams) (string, error) {
+	row := q.db.QueryRow(ctx, getGuildTimezone, arg.GuildID, arg.UserID)
+	var timezone string
+	err := row.Scan(&timezone)
+	return timezone, err
+}
+
 const getTimezone = `-- name: GetTimezone :one
 SELECT timezone FROM timezones WHERE user_id = $1
 `
@@ -20,6 +89,167 @@ func (q *Queries) GetTimezone(ctx context.Context, userID string) (string, error
 	return timezone, err
 }
 
+const getTimezoneAt = `-- name: GetTimezoneAt :one
+SELECT timezone FROM timezone_history WHERE user_id = $1 AND changed_at <= $2 ORDER BY changed_at DESC, id DESC LIMIT 1
+`
+
+type GetTimezoneAtParams struct {
+	UserID    string
+	ChangedAt time.Time
+}
+
+func (q *Queries) GetTimezoneAt(ctx context.Context, arg GetTimezoneAtParams) (string, error) {
+	row := q.db.QueryRow(ctx, getTimezoneAt, arg.UserID, arg.ChangedAt)
+	var timezone string
+	err := row.Scan(&timezone)
+	return timezone, err
+}
+
+const listPopularTimezones = `-- name: ListPopularTimezones :many
+SELECT timezone, COUNT(*) AS users FROM timezones GROUP BY timezone ORDER BY users DESC, timezone LIMIT $1
+`
+
+type ListPopularTimezonesRow struct {
+	Timezone string
+	Users    int64
+}
+
+func (q *Queries) ListPopularTimezones(ctx context.Context, maxZones int32) ([]ListPopularTimezonesRow, error) {
+	rows, err := q.db.Query(ctx, listPopularTimezones, maxZones)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPopularTimezonesRow
+	for rows.Next() {
+		var i ListPopularTimezonesRow
+		if err := rows.Scan(&i.Timezone, &i.Users); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTimezoneHistory = `-- name: ListTimezoneHistory :many
+SELECT timezone, changed_at FROM timezone_history WHERE user_id = $1 ORDER BY changed_at, id
+`
+
+type ListTimezoneHistoryRow struct {
+	Timezone  string
+	ChangedAt time.Time
+}
+
+func (q *Queries) ListTimezoneHistory(ctx context.Context, userID string) ([]ListTimezoneHistoryRow, error) {
+	rows, err := q.db.Query(ctx, listTimezoneHistory, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTimezoneHistoryRow
+	for rows.Next() {
+		var i ListTimezoneHistoryRow
+		if err := rows.Scan(&i.Timezone, &i.ChangedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTimezones = `-- name: ListTimezones :many
+SELECT user_id, timezone FROM timezones WHERE user_id > $1 ORDER BY user_id LIMIT $2
+`
+
+type ListTimezonesParams struct {
+	AfterUserID string
+	MaxRows     int32
+}
+
+func (q *Queries) ListTimezones(ctx context.Context, arg ListTimezonesParams) ([]Timezone, error) {
+	rows, err := q.db.Query(ctx, listTimezones, arg.AfterUserID, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Timezone
+	for rows.Next() {
+		var i Timezone
+		if err := rows.Scan(&i.UserID, &i.Timezone); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserGuildTimezones = `-- name: ListUserGuildTimezones :many
+SELECT guild_id, timezone FROM guild_timezones WHERE user_id = $1 ORDER BY guild_id
+`
+
+type ListUserGuildTimezonesRow struct {
+	GuildID  string
+	Timezone string
+}
+
+func (q *Queries) ListUserGuildTimezones(ctx context.Context, userID string) ([]ListUserGuildTimezonesRow, error) {
+	rows, err := q.db.Query(ctx, listUserGuildTimezones, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserGuildTimezonesRow
+	for rows.Next() {
+		var i ListUserGuildTimezonesRow
+		if err := rows.Scan(&i.GuildID, &i.Timezone); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setGuildDefaultTimezone = `-- name: SetGuildDefaultTimezone :exec
+INSERT INTO guild_timezone_defaults (guild_id, timezone) VALUES ($1, $2) ON CONFLICT (guild_id) DO UPDATE SET timezone = $2
+`
+
+type SetGuildDefaultTimezoneParams struct {
+	GuildID  string
+	Timezone string
+}
+
+func (q *Queries) SetGuildDefaultTimezone(ctx context.Context, arg SetGuildDefaultTimezoneParams) error {
+	_, err := q.db.Exec(ctx, setGuildDefaultTimezone, arg.GuildID, arg.Timezone)
+	return err
+}
+
+const setGuildTimezone = `-- name: SetGuildTimezone :exec
+INSERT INTO guild_timezones (guild_id, user_id, timezone) VALUES ($1, $2, $3) ON CONFLICT (guild_id, user_id) DO UPDATE SET timezone = $3
+`
+
+type SetGuildTimezoneParams struct {
+	GuildID  string
+	UserID   string
+	Timezone string
+}
+
+func (q *Queries) SetGuildTimezone(ctx context.Context, arg SetGuildTimezoneParams) error {
+	_, err := q.db.Exec(ctx, setGuildTimezone, arg.GuildID, arg.UserID, arg.Timezone)
+	return err
+}
+
 const setTimezone = `-- name: SetTimezone :exec
 INSERT INTO timezones (user_id, timezone) VALUES ($1, $2) ON CONFLICT (user_id) DO UPDATE SET timezone = $2
 `
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/SHA65536/TimezoneBot/database/sqlite"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/SHA65536/TimezoneBot/database"
+
+// startQuerySpan starts a span for running query, named after the sqlc query it comes from
+func startQuerySpan(ctx context.Context, system attribute.KeyValue, query string) (context.Context, trace.Span) {
+	name := queryName(query)
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(system, semconv.DBOperationName(name), semconv.DBQueryText(query)),
+	)
+}
+
+// endQuerySpan ends span, marking it failed if err is. Finding nothing is not a failure.
+func endQuerySpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// queryName returns the name sqlc gives a query in its leading "-- name: X :one" comment,
+// or the statement's first word for queries it didn't generate
+func queryName(query string) string {
+	if rest, ok := strings.CutPrefix(query, "-- name: "); ok {
+		if name, _, ok := strings.Cut(rest, " "); ok {
+			return name
+		}
+	}
+	if fields := strings.Fields(query); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+	return "query"
+}
+
+// pgxTracer traces the queries run on a Postgres connection
+type pgxTracer struct{}
+
+type pgxSpanKey struct{}
+
+func (pgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, span := startQuerySpan(ctx, semconv.DBSystemNamePostgreSQL, data.SQL)
+	return context.WithValue(ctx, pgxSpanKey{}, span)
+}
+
+func (pgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if span, ok := ctx.Value(pgxSpanKey{}).(trace.Span); ok {
+		endQuerySpan(span, data.Err)
+	}
+}
+
+// tracedDB traces the queries run on a SQLite database or transaction
+type tracedDB struct {
+	db sqlite.DBTX
+}
+
+var _ sqlite.DBTX = tracedDB{}
+
+func (t tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, semconv.DBSystemNameSQLite, query)
+	res, err := t.db.ExecContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return res, err
+}
+
+func (t tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.db.PrepareContext(ctx, query)
+}
+
+func (t tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, semconv.DBSystemNameSQLite, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+func (t tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := startQuerySpan(ctx, semconv.DBSystemNameSQLite, query)
+	row := t.db.QueryRowContext(ctx, query, args...)
+	endQuerySpan(span, row.Err())
+	return row
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans sends the spans started during the test to an in-memory exporter
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"-- name: GetTimezone :one\nSELECT timezone FROM timezones", "GetTimezone"},
+		{"select 1", "SELECT"},
+		{"", "query"},
+	}
+	for _, tt := range tests {
+		if got := queryName(tt.query); got != tt.want {
+			t.Errorf("queryName(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteStore_Tracing(t *testing.T) {
+	exporter := recordSpans(t)
+	ctx := context.Background()
+	store := newTestSQLiteStore(t)
+
+	store.GetTimezone(ctx, "1")
+	store.InTx(ctx, func(q Querier) error {
+		return q.SetTimezone(ctx, SetTimezoneParams{UserID: "1", Timezone: "Europe/London"})
+	})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 || spans[0].Name != "GetTimezone" || spans[1].Name != "SetTimezone" {
+		t.Fatalf("spans = %v, want GetTimezone and SetTimezone", spans)
+	}
+	// Missing rows are an expected outcome, not an error
+	if spans[0].Status.Code != codes.Unset {
+		t.Errorf("GetTimezone span status = %v, want unset", spans[0].Status)
+	}
+}
@@ -0,0 +1,106 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/ical"
+	"github.com/bwmarrin/discordgo"
+)
+
+const calendarButtonID = "convert_ics"
+
+// maxSummaryLength keeps event titles short enough to be readable in calendar apps
+const maxSummaryLength = 80
+
+// calendarButton returns the "Add to calendar" button for a converted instant
+func calendarButton(unixTimestamp int64) discordgo.Button {
+	return discordgo.Button{
+		Label:    "Add to calendar",
+		Style:    discordgo.SecondaryButton,
+		Emoji:    discordgo.ComponentEmoji{Name: "📅"},
+		CustomID: fmt.Sprintf("%s:%d", calendarButtonID, unixTimestamp),
+	}
+}
+
+// handleCalendarButton answers a calendar button click with an .ics attachment
+func (h *handlers) handleCalendarButton(ctx context.Context, i *discordgo.InteractionCreate, arg string) {
+	unixTimestamp, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		loggerFrom(ctx).Warn("malformed calendar button", slog.String("custom_id", i.MessageComponentData().CustomID))
+		return
+	}
+	start := time.Unix(unixTimestamp, 0).UTC()
+
+	event := ical.Event{
+		UID:     fmt.Sprintf("%d-%s@timezonebot", unixTimestamp, i.Message.ID),
+		Stamp:   h.clock.Now().UTC(),
+		Start:   start,
+		Summary: "Discord event",
+	}
+
+	// Describe the event using the message that was converted, if it can still be fetched
+	if ref := i.Message.MessageReference; ref != nil {
+		src, err := h.session.ChannelMessage(ref.ChannelID, ref.MessageID, discordgo.WithContext(ctx))
+		if err == nil {
+			event.Summary = summarize(src.Content)
+			event.Description = src.Content
+			event.URL = messageURL(i.GuildID, src.ChannelID, src.ID)
+		} else {
+			loggerFrom(ctx).Info("converted message is gone, using a generic summary", slog.Any("error", err))
+		}
+	}
+
+	cal := &ical.Calendar{
+		ProdID: "-//TimezoneBot//EN",
+		Events: []ical.Event{event},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		loggerFrom(ctx).Error("failed to encode calendar", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to create calendar event.")
+		return
+	}
+
+	err = h.session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Event at <t:%d:F>", unixTimestamp),
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Files: []*discordgo.File{{
+				Name:        "event.ics",
+				ContentType: "text/calendar",
+				Reader:      &buf,
+			}},
+		},
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		loggerFrom(ctx).Warn("failed to send calendar event", slog.Any("error", err))
+	}
+}
+
+// messageURL links to a message, guildID is empty for messages in DMs
+func messageURL(guildID, channelID, messageID string) string {
+	if guildID == "" {
+		guildID = "@me"
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
+
+// summarize shortens a message to a single line suitable for an event title
+func summarize(content string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	if runes := []rune(line); len(runes) > maxSummaryLength {
+		line = string(runes[:maxSummaryLength-1]) + "…"
+	}
+	if line == "" {
+		return "Discord event"
+	}
+	return line
+}
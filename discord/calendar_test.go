@@ -0,0 +1,47 @@
+package discord
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/SHA65536/TimezoneBot/ical"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestHandlers_CalendarButtonInDM(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	src := userMessage("2", "1", "dinner at 18:00", testNow)
+	src.GuildID = ""
+	bot.session.post(src)
+
+	bot.onInteraction(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionMessageComponent,
+		ChannelID: "20",
+		User:      &discordgo.User{ID: "1"},
+		Message: &discordgo.Message{
+			ID:               "3",
+			MessageReference: &discordgo.MessageReference{ChannelID: "20", MessageID: "2"},
+		},
+		Data: discordgo.MessageComponentInteractionData{CustomID: calendarButton(testNow.Unix()).CustomID},
+	}})
+
+	resp := bot.session.responses[len(bot.session.responses)-1]
+	if len(resp.Data.Files) != 1 {
+		t.Fatalf("calendar button response has %d files, want the event", len(resp.Data.Files))
+	}
+	raw, err := io.ReadAll(resp.Data.Files[0].Reader)
+	if err != nil {
+		t.Fatalf("reading the event: %v", err)
+	}
+	cal, err := ical.Decode(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if got, want := cal.Events[0].URL, "https://discord.com/channels/@me/20/2"; got != want {
+		t.Errorf("event URL = %q, want %q", got, want)
+	}
+	if got := cal.Events[0].Summary; got != "dinner at 18:00" {
+		t.Errorf("event summary = %q, want the message", got)
+	}
+}
@@ -0,0 +1,201 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// command is a slash command along with the handler of its invocations
+type command struct {
+	definition *discordgo.ApplicationCommand
+	handle     func(h *handlers, ctx context.Context, i *discordgo.InteractionCreate)
+}
+
+// commands declares every slash command of the bot, both what is registered with Discord
+// and how invocations are routed
+func commands() []command {
+	return []command{
+		{timezoneCommand(), (*handlers).handleTimezoneCommand},
+		{travelCommand(), (*handlers).handleTravelCommand},
+		{settingsCommand(), (*handlers).handleSettingsCommand},
+		{privacyCommand(), (*handlers).handlePrivacyCommand},
+	}
+}
+
+// findCommand returns the slash command called name, if the bot has one
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.definition.Name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// definitions returns the slash commands to register with Discord
+func definitions() []*discordgo.ApplicationCommand {
+	commands := commands()
+	definitions := make([]*discordgo.ApplicationCommand, len(commands))
+	for n, cmd := range commands {
+		definitions[n] = cmd.definition
+	}
+	return definitions
+}
+
+// SyncCommands registers the bot's slash commands without connecting to the gateway,
+// globally or to the guild guildID when it is set
+func SyncCommands(ctx context.Context, token, guildID string, log *slog.Logger) error {
+	dg, appID, err := commandSession(token)
+	if err != nil {
+		return err
+	}
+	return syncCommands(ctx, dg, appID, guildID, definitions(), log)
+}
+
+// PurgeCommands removes every slash command of the bot, globally or from the guild guildID
+// when it is set
+func PurgeCommands(ctx context.Context, token, guildID string, log *slog.Logger) error {
+	dg, appID, err := commandSession(token)
+	if err != nil {
+		return err
+	}
+	return syncCommands(ctx, dg, appID, guildID, []*discordgo.ApplicationCommand{}, log)
+}
+
+// commandSession creates a REST-only session for the bot's token, along with its application ID
+func commandSession(token string) (*discordgo.Session, string, error) {
+	dg, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create Discord session: %w", err)
+	}
+	dg.Client.Transport = tracingTransport{base: http.DefaultTransport}
+
+	app, err := dg.Application("@me")
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching application: %w", err)
+	}
+	return dg, app.ID, nil
+}
+
+// commandAPI is the part of the Discord API managing slash commands
+type commandAPI interface {
+	ApplicationCommands(appID, guildID string, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+	ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+}
+
+var _ commandAPI = (*discordgo.Session)(nil)
+
+// syncCommands makes the slash commands of the application appID match declared, overwriting
+// them all at once only when they differ. Commands are registered globally unless guildID is
+// set, guild commands show up instantly where global ones take a while to propagate.
+func syncCommands(ctx context.Context, api commandAPI, appID, guildID string, declared []*discordgo.ApplicationCommand, log *slog.Logger) error {
+	if guildID != "" {
+		log = log.With(slog.String("guild_id", guildID))
+	}
+
+	registered, err := api.ApplicationCommands(appID, guildID, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("cannot list slash commands: %w", err)
+	}
+
+	if sameCommands(registered, declared) {
+		log.Debug("slash commands are up to date", slog.Int("commands", len(declared)))
+		return nil
+	}
+
+	if _, err := api.ApplicationCommandBulkOverwrite(appID, guildID, declared, discordgo.WithContext(ctx)); err != nil {
+		return fmt.Errorf("cannot overwrite slash commands: %w", err)
+	}
+	log.Info("updated slash commands", slog.Int("commands", len(declared)), slog.Int("previous", len(registered)))
+	return nil
+}
+
+// sameCommands reports whether the registered commands are the declared ones, ignoring
+// their order and the fields Discord fills in
+func sameCommands(registered, declared []*discordgo.ApplicationCommand) bool {
+	return canonicalCommands(registered) == canonicalCommands(declared)
+}
+
+// canonicalCommands encodes the declarable part of commands, sorted by name
+func canonicalCommands(commands []*discordgo.ApplicationCommand) string {
+	canonical := make([]canonicalCommand, len(commands))
+	for n, cmd := range commands {
+		canonical[n] = canonicalCommand{
+			Type:                     cmd.Type,
+			Name:                     cmd.Name,
+			Description:              cmd.Description,
+			DefaultMemberPermissions: cmd.DefaultMemberPermissions,
+			Options:                  canonicalOptions(cmd.Options),
+		}
+		if canonical[n].Type == 0 {
+			// Discord reports the default type of the commands declared without one
+			canonical[n].Type = discordgo.ChatApplicationCommand
+		}
+	}
+	slices.SortFunc(canonical, func(a, b canonicalCommand) int { return strings.Compare(a.Name, b.Name) })
+
+	// Choice values decode as float64 where they were declared as ints, JSON puts them on par
+	encoded, _ := json.Marshal(canonical)
+	return string(encoded)
+}
+
+func canonicalOptions(options []*discordgo.ApplicationCommandOption) []canonicalOption {
+	if len(options) == 0 {
+		return nil
+	}
+	canonical := make([]canonicalOption, len(options))
+	for n, opt := range options {
+		canonical[n] = canonicalOption{
+			Type:         opt.Type,
+			Name:         opt.Name,
+			Description:  opt.Description,
+			ChannelTypes: opt.ChannelTypes,
+			Required:     opt.Required,
+			Options:      canonicalOptions(opt.Options),
+			Autocomplete: opt.Autocomplete,
+			MinValue:     opt.MinValue,
+			MaxValue:     opt.MaxValue,
+			MinLength:    opt.MinLength,
+			MaxLength:    opt.MaxLength,
+		}
+		for _, choice := range opt.Choices {
+			canonical[n].Choices = append(canonical[n].Choices, canonicalChoice{choice.Name, choice.Value})
+		}
+	}
+	return canonical
+}
+
+type canonicalCommand struct {
+	Type                     discordgo.ApplicationCommandType `json:"type"`
+	Name                     string                           `json:"name"`
+	Description              string                           `json:"description,omitempty"`
+	DefaultMemberPermissions *int64                           `json:"default_member_permissions,omitempty"`
+	Options                  []canonicalOption                `json:"options,omitempty"`
+}
+
+type canonicalOption struct {
+	Type         discordgo.ApplicationCommandOptionType `json:"type"`
+	Name         string                                 `json:"name"`
+	Description  string                                 `json:"description,omitempty"`
+	ChannelTypes []discordgo.ChannelType                `json:"channel_types,omitempty"`
+	Required     bool                                   `json:"required,omitempty"`
+	Options      []canonicalOption                      `json:"options,omitempty"`
+	Autocomplete bool                                   `json:"autocomplete,omitempty"`
+	Choices      []canonicalChoice                      `json:"choices,omitempty"`
+	MinValue     *float64                               `json:"min_value,omitempty"`
+	MaxValue     float64                                `json:"max_value,omitempty"`
+	MinLength    *int                                   `json:"min_length,omitempty"`
+	MaxLength    int                                    `json:"max_length,omitempty"`
+}
+
+type canonicalChoice struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
@@ -0,0 +1,119 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeCommandAPI stands in for the slash commands Discord has registered
+type fakeCommandAPI struct {
+	registered map[string][]*discordgo.ApplicationCommand
+	overwrites int
+}
+
+func (f *fakeCommandAPI) ApplicationCommands(appID, guildID string, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	return f.registered[guildID], nil
+}
+
+func (f *fakeCommandAPI) ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	f.overwrites++
+	f.registered[guildID] = asRegistered(commands)
+	return f.registered[guildID], nil
+}
+
+// asRegistered returns commands the way Discord lists them once registered: decoded from JSON,
+// with the fields it fills in and in another order
+func asRegistered(commands []*discordgo.ApplicationCommand) []*discordgo.ApplicationCommand {
+	encoded, _ := json.Marshal(commands)
+	var registered []*discordgo.ApplicationCommand
+	json.Unmarshal(encoded, &registered)
+
+	dmPermission := true
+	for _, cmd := range registered {
+		cmd.ID = "1234"
+		cmd.ApplicationID = "app"
+		cmd.Version = "5678"
+		cmd.Type = discordgo.ChatApplicationCommand
+		cmd.DMPermission = &dmPermission
+	}
+	slices.Reverse(registered)
+	return registered
+}
+
+func TestSyncCommands(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	declared := definitions()
+
+	api := &fakeCommandAPI{registered: map[string][]*discordgo.ApplicationCommand{}}
+	if err := syncCommands(ctx, api, "app", "", declared, log); err != nil {
+		t.Fatalf("syncCommands() unexpected error: %v", err)
+	}
+	if api.overwrites != 1 || len(api.registered[""]) != len(declared) {
+		t.Fatalf("syncCommands() overwrote %d times registering %d commands, want the %d declared at once", api.overwrites, len(api.registered[""]), len(declared))
+	}
+
+	// Nothing changed since
+	if err := syncCommands(ctx, api, "app", "", declared, log); err != nil {
+		t.Fatalf("syncCommands() unexpected error: %v", err)
+	}
+	if api.overwrites != 1 {
+		t.Errorf("syncCommands() overwrote unchanged commands")
+	}
+
+	changed := asRegistered(declared)
+	changed[0].Description = "Something else"
+	if err := syncCommands(ctx, api, "app", "", changed, log); err != nil {
+		t.Fatalf("syncCommands() unexpected error: %v", err)
+	}
+	if api.overwrites != 2 {
+		t.Errorf("syncCommands() didn't overwrite a changed command")
+	}
+
+	// Guild commands are separate from global ones
+	if err := syncCommands(ctx, api, "app", "guild", declared, log); err != nil {
+		t.Fatalf("syncCommands() unexpected error: %v", err)
+	}
+	if api.overwrites != 3 || len(api.registered["guild"]) != len(declared) {
+		t.Errorf("syncCommands() didn't register the commands to the guild")
+	}
+}
+
+func TestSameCommands(t *testing.T) {
+	declared := definitions()
+	if !sameCommands(asRegistered(declared), declared) {
+		t.Error("sameCommands() = false for the registered declared commands")
+	}
+
+	missing := asRegistered(declared)[1:]
+	if sameCommands(missing, declared) {
+		t.Error("sameCommands() = true with a command missing")
+	}
+
+	choice := asRegistered(declared)
+	for _, cmd := range choice {
+		if cmd.Name == "settings" {
+			cmd.Options[0].Options[0].Choices = cmd.Options[0].Options[0].Choices[1:]
+		}
+	}
+	if sameCommands(choice, declared) {
+		t.Error("sameCommands() = true with a choice removed")
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	for _, def := range definitions() {
+		if cmd, ok := findCommand(def.Name); !ok || cmd.handle == nil {
+			t.Errorf("findCommand(%q) has no handler", def.Name)
+		}
+	}
+	if _, ok := findCommand("unknown"); ok {
+		t.Error("findCommand(\"unknown\") found a command")
+	}
+}
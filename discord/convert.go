@@ -2,122 +2,330 @@ package discord
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
-	"sync"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/SHA65536/TimezoneBot/database"
-	"github.com/SHA65536/TimezoneBot/parser"
 	"github.com/bwmarrin/discordgo"
 )
 
-var cooldownTable = map[string]time.Time{}
-var cooldownLock sync.RWMutex
+const (
+	// autoConvertBurst is how many automatic conversions a channel can get at once
+	autoConvertBurst = 5
+	// autoConvertInterval is how often a channel earns another automatic conversion
+	autoConvertInterval = 30 * time.Second
 
-func RegisterConvertHandler(s *discordgo.Session, db *database.Queries) error {
-	var tp = parser.NewTimeParserWithFormats(parser.Format24Hour, parser.Format12Hour, parser.FormatSimpleHour)
+	// defaultCooldown is how long a message can't be converted again after a conversion
+	defaultCooldown = 10 * time.Minute
+)
 
-	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
-		if m.Author.ID == s.State.User.ID {
-			return
-		}
+// onMessageCreate looks for times in new messages and converts them or offers to, depending on the channel mode
+func (h *handlers) onMessageCreate(m *discordgo.MessageCreate) {
+	if h.isBot(m.Author.ID) {
+		return
+	}
+	ctx, span := h.messageContext("MessageCreate", m.GuildID, m.ChannelID, m.Author.ID, m.ID)
+	defer span.End()
+	log := loggerFrom(ctx)
 
-		// Try to parse time from the message
-		if _, err := tp.ParseTimeFromMessage(m.Content); err != nil {
-			return
-		}
+	// Try to parse time from the message. Most messages have none, so this goes before any lookup.
+	h.metrics.MessageScanned()
+	_, format, err := h.parseTime(ctx, m.Content)
+	if err != nil {
+		return
+	}
+	h.metrics.TimeMatched(format.Name)
+
+	mode := channelMode(ctx, h.db, m.ChannelID)
+	if mode == ChannelModeOff {
+		return
+	}
+
+	// Check if owner has a timezone set
+	if _, err := resolveTimezone(ctx, h.db, m.GuildID, m.Author.ID); err != nil {
+		logLookupError(ctx, err, "failed to look up author timezone")
+		log.Debug("ignoring time from author without a timezone")
+		return
+	}
 
-		// Check if owner has a timezone set
-		if _, err := db.GetTimezone(context.Background(), m.Author.ID); err != nil {
+	// Busy channels fall back to a reaction once they run out of automatic conversions
+	if mode == ChannelModeAutoReply || mode == ChannelModeAutoThread {
+		if !h.limiter.Allow(m.ChannelID) {
+			log.Info("channel is out of automatic conversions, reacting instead", slog.String("mode", mode))
+		} else if err := h.convertWithCooldown(ctx, m.Message, mode == ChannelModeAutoThread); err != nil {
+			log.Warn("automatic conversion failed, reacting instead", slog.String("mode", mode), slog.Any("error", err))
+		} else {
 			return
 		}
+	}
 
-		fmt.Println(s.MessageReactionAdd(m.ChannelID, m.ID, "⏰"))
+	if err := h.session.MessageReactionAdd(m.ChannelID, m.ID, "⏰", discordgo.WithContext(ctx)); err != nil {
+		log.Warn("failed to add reaction", slog.Any("error", err))
+		return
+	}
+	h.metrics.ReactionAdded()
+}
 
-		// Clean cooldowns every once in a while (too lazy to write a cronjob)
-		if rand.Intn(1000) == 0 {
-			cleancooldown()
-		}
-	})
+// onReactionAdd converts a message when someone clicks its ⏰ reaction
+func (h *handlers) onReactionAdd(m *discordgo.MessageReactionAdd) {
+	if m.Emoji.Name != "⏰" || h.isBot(m.UserID) {
+		return
+	}
+	ctx, span := h.messageContext("MessageReactionAdd", m.GuildID, m.ChannelID, m.UserID, m.MessageID)
+	defer span.End()
+	log := loggerFrom(ctx)
 
-	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageReactionAdd) {
-		if m.Emoji.Name != "⏰" || m.UserID == s.State.User.ID {
-			return
-		}
+	// Fetch the original message
+	msg, err := h.session.ChannelMessage(m.ChannelID, m.MessageID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Warn("failed to fetch reacted message", slog.Any("error", err))
+		return
+	}
+	// Fetched messages don't carry the guild, which the timezone and settings lookups need
+	msg.GuildID = m.GuildID
 
-		// Fetch the original message
-		msg, err := s.ChannelMessage(m.ChannelID, m.MessageID)
-		if err != nil {
-			return
-		}
+	if err := h.convertWithCooldown(ctx, msg, false); errors.Is(err, errOnCooldown) {
+		log.Debug("ignoring reaction on a message converted recently")
+	} else if err != nil {
+		log.Warn("failed to convert reacted message", slog.Any("error", err))
+	}
+}
 
-		// Check if the original message author has a timezone set
-		userTimezone, err := db.GetTimezone(context.Background(), msg.Author.ID)
-		if err != nil {
-			return
+// onMessageUpdate keeps the conversion reply in sync when the original message is edited
+func (h *handlers) onMessageUpdate(m *discordgo.MessageUpdate) {
+	if m.Author != nil && h.isBot(m.Author.ID) {
+		return
+	}
+	userID := ""
+	if m.Author != nil {
+		userID = m.Author.ID
+	}
+	ctx, span := h.messageContext("MessageUpdate", m.GuildID, m.ChannelID, userID, m.ID)
+	defer span.End()
+	log := loggerFrom(ctx)
+
+	// Update events without content, like link embeds loading, leave the text as it was
+	if m.Content == "" {
+		return
+	}
+
+	// The content is checked first, edits without a time only need a reply that may be stale
+	// removed, which spares fetching the message
+	if _, _, err := h.parseTime(ctx, m.Content); err != nil {
+		if err := h.removeConversion(ctx, m.ID); err != nil {
+			log.Warn("failed to remove stale conversion", slog.Any("error", err))
 		}
+		return
+	}
 
-		// Try to parse time from the original message content
-		seconds, err := tp.ParseTimeFromMessage(msg.Content)
-		if err != nil {
-			return
+	// Only messages that were already converted are of interest
+	if _, err := h.db.GetConvertReply(ctx, m.ID); err != nil {
+		logLookupError(ctx, err, "failed to look up conversion reply")
+		return
+	}
+
+	// Update events can be partial, fetch the whole message
+	msg, err := h.session.ChannelMessage(m.ChannelID, m.ID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Warn("failed to fetch edited message", slog.Any("error", err))
+		return
+	}
+	msg.GuildID = m.GuildID
+
+	if err := h.sendConversion(ctx, msg, false); err != nil {
+		log.Warn("failed to update conversion", slog.Any("error", err))
+	}
+}
+
+// handleComponent handles the buttons on the conversion reply
+func (h *handlers) handleComponent(ctx context.Context, i *discordgo.InteractionCreate) {
+	id, arg, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	switch id {
+	case localButtonID:
+		h.handleLocalButton(ctx, i, arg)
+	case calendarButtonID:
+		h.handleCalendarButton(ctx, i, arg)
+	case privacyDeleteButtonID:
+		h.handlePrivacyDeleteButton(ctx, i, arg)
+	case privacyCancelButtonID:
+		h.handlePrivacyCancelButton(ctx, i)
+	default:
+		loggerFrom(ctx).Warn("unknown button clicked", slog.String("custom_id", i.MessageComponentData().CustomID))
+	}
+}
+
+// errOnCooldown is returned when a message was converted too recently to be converted again
+var errOnCooldown = errors.New("message is on cooldown")
+
+// convertWithCooldown sends a conversion for msg unless one was sent recently
+func (h *handlers) convertWithCooldown(ctx context.Context, msg *discordgo.Message, inThread bool) error {
+	d := guildCooldown(ctx, h.db, msg.GuildID)
+	ok, err := h.cooldowns.Acquire(ctx, msg.ID, d)
+	if err != nil {
+		return fmt.Errorf("failed to check cooldown: %w", err)
+	}
+	if !ok {
+		h.metrics.CooldownSuppressed()
+		return errOnCooldown
+	}
+
+	if err := h.sendConversion(ctx, msg, inThread); err != nil {
+		// Nothing was posted, let the next attempt go through
+		if err := h.cooldowns.Release(ctx, msg.ID); err != nil {
+			loggerFrom(ctx).Warn("failed to release cooldown", slog.Any("error", err))
 		}
+		return err
+	}
+	return nil
+}
 
-		// Check cooldown
-		cooldownLock.RLock()
-		if time.Since(cooldownTable[msg.ID]) < 10*time.Minute {
-			cooldownLock.RUnlock()
-			return
+// guildCooldown returns the conversion cooldown configured for a guild, defaultCooldown if none is set
+func guildCooldown(ctx context.Context, db database.Querier, guildID string) time.Duration {
+	if guildID == "" {
+		return defaultCooldown
+	}
+	seconds, err := db.GetGuildCooldown(ctx, guildID)
+	if err != nil || seconds <= 0 {
+		logLookupError(ctx, err, "failed to look up guild cooldown")
+		return defaultCooldown
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sendConversion converts the time in msg from its author's timezone and posts it,
+// either as a reply or in a new thread started from the message
+func (h *handlers) sendConversion(ctx context.Context, msg *discordgo.Message, inThread bool) error {
+	// Check if the original message author has a timezone set
+	// Use the timezone the author was in when posting, they may have moved since
+	userTimezone, err := resolveTimezoneAt(ctx, h.db, msg.GuildID, msg.Author.ID, msg.Timestamp)
+	if err != nil {
+		return fmt.Errorf("looking up author timezone: %w", err)
+	}
+
+	// Try to parse time from the original message content
+	seconds, _, err := h.parseTime(ctx, msg.Content)
+	if err != nil {
+		return err
+	}
+
+	// Load the user's timezone
+	userLoc, err := time.LoadLocation(userTimezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", userTimezone, err)
+	}
+
+	// Create the time on the day the message was posted, in the user's timezone
+	converted := conversionTime(msg.Timestamp, userLoc, seconds)
+	parsedTime := converted.Time
+
+	// Format the time message using the preferred Discord timestamp style,
+	// explaining times that were moved or are ambiguous because of a DST change
+	unixTimestamp := parsedTime.Unix()
+	style := resolveTimestampStyle(ctx, h.db, msg.Author.ID, msg.GuildID)
+	timeMessage := formatTimestamp(unixTimestamp, conversionStyle(style, parsedTime, h.clock.Now(), userLoc))
+	if note := dstNote(converted, userLoc); note != "" {
+		timeMessage += "\n*" + note + "*"
+	}
+
+	reply := &discordgo.MessageSend{
+		Content: timeMessage,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				localButton(unixTimestamp, msg.Author.ID, msg.Timestamp),
+				calendarButton(unixTimestamp),
+			}},
+		},
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Parse: []discordgo.AllowedMentionType{},
+		},
+	}
+
+	// Edit the reply that was already posted for this message instead of posting a duplicate
+	if existing, err := h.db.GetConvertReply(ctx, msg.ID); err == nil {
+		_, err := h.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:              existing.ReplyID,
+			Channel:         existing.ReplyChannelID,
+			Content:         &reply.Content,
+			Components:      reply.Components,
+			AllowedMentions: reply.AllowedMentions,
+		}, discordgo.WithContext(ctx))
+		if err == nil {
+			h.metrics.ConversionSent()
+			return nil
 		}
-		cooldownLock.RUnlock()
+		// The reply is gone, fall through and post a new one
+		loggerFrom(ctx).Info("failed to edit conversion reply, posting a new one", slog.Any("error", err))
+	} else {
+		logLookupError(ctx, err, "failed to look up conversion reply")
+	}
 
-		// Load the user's timezone
-		userLoc, err := time.LoadLocation(userTimezone)
+	channelID := msg.ChannelID
+	if inThread {
+		thread, err := h.session.MessageThreadStartComplex(msg.ChannelID, msg.ID, &discordgo.ThreadStart{
+			Name:                "Time conversion",
+			AutoArchiveDuration: 60,
+		}, discordgo.WithContext(ctx))
 		if err != nil {
-			return
+			loggerFrom(ctx).Warn("failed to start thread, replying in the channel", slog.Any("error", err))
+		} else {
+			channelID = thread.ID
 		}
+	}
+	// Replies can only reference messages in the same channel
+	if channelID == msg.ChannelID {
+		reply.Reference = &discordgo.MessageReference{
+			MessageID: msg.ID,
+			ChannelID: msg.ChannelID,
+			GuildID:   msg.GuildID,
+		}
+	}
 
-		// Create the time in the user's timezone context
-		now := time.Now().In(userLoc)
-		parsedTime := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, userLoc).Add(time.Duration(seconds) * time.Second)
-
-		// Convert to UTC for Discord timestamp
-		utcTime := parsedTime.UTC()
-
-		// Format the time message using Discord timestamp format
-		unixTimestamp := utcTime.Unix()
-		timeMessage := fmt.Sprintf("<t:%d:t>", unixTimestamp)
-
-		_, err = s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
-			Content: timeMessage,
-			Reference: &discordgo.MessageReference{
-				MessageID: msg.ID,
-				ChannelID: msg.ChannelID,
-				GuildID:   msg.GuildID,
-			},
-			AllowedMentions: &discordgo.MessageAllowedMentions{
-				Parse: []discordgo.AllowedMentionType{},
-			},
-		})
+	sent, err := h.session.ChannelMessageSendComplex(channelID, reply, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to send conversion: %w", err)
+	}
+	h.metrics.ConversionSent()
 
-		if err == nil {
-			cooldownLock.Lock()
-			cooldownTable[msg.ID] = time.Now()
-			cooldownLock.Unlock()
-		}
+	err = h.db.SetConvertReply(ctx, database.SetConvertReplyParams{
+		MessageID:      msg.ID,
+		ReplyChannelID: sent.ChannelID,
+		ReplyID:        sent.ID,
 	})
+	if err != nil {
+		return fmt.Errorf("failed to save conversion reply: %w", err)
+	}
 
 	return nil
 }
 
-func cleancooldown() {
-	var newTable = map[string]time.Time{}
-	cooldownLock.Lock()
-	defer cooldownLock.Unlock()
-	for id, t := range cooldownTable {
-		if time.Since(t) < 10*time.Minute {
-			newTable[id] = t
-		}
+// conversionTime returns the instant seconds after midnight on the day posted falls on in loc
+func conversionTime(posted time.Time, loc *time.Location, seconds uint) localInstant {
+	day := posted.In(loc)
+	return resolveWallClock(day.Year(), day.Month(), day.Day(), seconds, loc)
+}
+
+// conversionStyle adds the date to style when converted isn't today for the author as of now
+func conversionStyle(style string, converted, now time.Time, loc *time.Location) string {
+	if !sameDay(converted, now, loc) {
+		return withDate(style)
+	}
+	return style
+}
+
+// removeConversion deletes the reply posted for a message, if there is one
+func (h *handlers) removeConversion(ctx context.Context, messageID string) error {
+	existing, err := h.db.GetConvertReply(ctx, messageID)
+	if err != nil {
+		logLookupError(ctx, err, "failed to look up conversion reply")
+		return nil
 	}
+
+	if err := h.session.ChannelMessageDelete(existing.ReplyChannelID, existing.ReplyID, discordgo.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete conversion reply: %w", err)
+	}
+
+	return h.db.DeleteConvertReply(ctx, messageID)
 }
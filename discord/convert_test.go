@@ -0,0 +1,99 @@
+package discord
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q) unexpected error: %v", name, err)
+	}
+	return loc
+}
+
+func TestConversionTime(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	london := mustLoadLocation(t, "Europe/London")
+
+	tests := []struct {
+		name    string
+		posted  time.Time
+		loc     *time.Location
+		seconds uint
+		want    time.Time
+	}{
+		{
+			// 23:30 in New York is already the next day in UTC
+			name:    "evening message is anchored on the author's day",
+			posted:  time.Date(2026, 3, 2, 4, 30, 0, 0, time.UTC),
+			loc:     newYork,
+			seconds: 23 * 3600,
+			want:    time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "early message stays on the author's day",
+			posted:  time.Date(2026, 3, 1, 5, 30, 0, 0, time.UTC),
+			loc:     newYork,
+			seconds: 9 * 3600,
+			want:    time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "morning after clocks go forward",
+			posted:  time.Date(2026, 3, 29, 12, 0, 0, 0, time.UTC),
+			loc:     london,
+			seconds: 10 * 3600,
+			want:    time.Date(2026, 3, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "morning after clocks go back",
+			posted:  time.Date(2026, 10, 25, 12, 0, 0, 0, time.UTC),
+			loc:     london,
+			seconds: 10 * 3600,
+			want:    time.Date(2026, 10, 25, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "midnight",
+			posted:  time.Date(2026, 7, 12, 23, 59, 0, 0, time.UTC),
+			loc:     london,
+			seconds: 0,
+			want:    time.Date(2026, 7, 12, 23, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conversionTime(tt.posted, tt.loc, tt.seconds).Time; !got.Equal(tt.want) {
+				t.Errorf("conversionTime() = %v, want %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestConversionStyle(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	// A message posted at 23:30 on March 1st in New York mentioning 11pm
+	posted := time.Date(2026, 3, 2, 4, 30, 0, 0, time.UTC)
+	converted := conversionTime(posted, newYork, 23*3600).Time
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want string
+	}{
+		{"reacted the same evening", time.Date(2026, 3, 2, 4, 45, 0, 0, time.UTC), "t"},
+		{"reacted after midnight", time.Date(2026, 3, 2, 5, 15, 0, 0, time.UTC), "f"},
+		{"reacted days later", time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC), "f"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conversionStyle("t", converted, tt.now, newYork); got != tt.want {
+				t.Errorf("conversionStyle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
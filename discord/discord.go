@@ -1,56 +1,235 @@
 package discord
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/SHA65536/TimezoneBot/cooldown"
 	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/SHA65536/TimezoneBot/metrics"
 	"github.com/bwmarrin/discordgo"
 )
 
 // DiscordServer wraps the Discord session and database
 // Implements Start and Stop methods
-// Delegates slash command handling to timezone.go
+// Delegates event handling to handlers.go
+
+// jobInterval is how often expired cooldowns are removed, finished trips reverted and old
+// conversion replies forgotten
+const jobInterval = time.Minute
+
+// convertReplyRetention is how long a conversion reply is kept in sync with edits of its
+// message. Edits of older messages leave the reply as it is.
+const convertReplyRetention = 7 * 24 * time.Hour
+
+// maxHeartbeatAge is how long the gateway can go without acknowledging a heartbeat before the
+// bot is considered wedged. Discord asks for a heartbeat about every 41 seconds and discordgo
+// reconnects by itself after missing a few, so this only trips when reconnecting doesn't help.
+const maxHeartbeatAge = 5 * time.Minute
 
 type DiscordServer struct {
-	session *discordgo.Session
-	db      *database.Queries
+	token    string
+	sharding Sharding
+	devGuild string
+	// session makes the REST calls of the handlers and connects the first shard
+	session   *discordgo.Session
+	db        database.Store
+	cooldowns cooldown.Store
+	handlers  *handlers
+	log       *slog.Logger
+	jobs      backgroundJobs
+
+	mu     sync.RWMutex
+	shards []*discordgo.Session
 }
 
-// MakeDiscordServer creates a new DiscordServer
-func MakeDiscordServer(token string, db *database.Queries) (*DiscordServer, error) {
-	dg, err := discordgo.New("Bot " + token)
+// MakeDiscordServer creates a new DiscordServer connecting the shards picked by sharding,
+// which logs to log and records its activity in m. Slash commands are registered to the
+// guild devGuild alone when it is set.
+func MakeDiscordServer(token string, sharding Sharding, devGuild string, db database.Store, cooldowns cooldown.Store, log *slog.Logger, m *metrics.Metrics) (*DiscordServer, error) {
+	if err := sharding.validate(); err != nil {
+		return nil, err
+	}
+
+	dg, err := newGatewaySession(token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Discord session: %w", err)
+		return nil, err
 	}
 
-	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsGuildMessageReactions
+	// Handlers are added before the session is opened so events sent right after connecting aren't missed,
+	// the bot's user ID is filled in once the gateway is ready
+	h := newHandlers(dg, "", db, cooldowns, systemClock{}, log, m)
+	h.addTo(dg)
 
 	return &DiscordServer{
-		session: dg,
-		db:      db,
+		token:     token,
+		sharding:  sharding,
+		devGuild:  devGuild,
+		session:   dg,
+		db:        db,
+		cooldowns: cooldowns,
+		handlers:  h,
+		log:       log,
+		shards:    []*discordgo.Session{dg},
 	}, nil
 }
 
-// Start opens the Discord session
-func (s *DiscordServer) Start() error {
-	if err := s.session.Open(); err != nil {
-		return fmt.Errorf("error opening Discord session: %w", err)
+// Start connects the gateway shards, syncs the slash commands and starts the background
+// jobs, which run until ctx is cancelled or Stop is called
+func (s *DiscordServer) Start(ctx context.Context) error {
+	count, concurrency, err := s.shardPlan()
+	if err != nil {
+		return err
+	}
+	s.log.Info("connecting to the gateway", slog.Int("shard_count", count), slog.Any("shards", s.sharding.ids(count)))
+
+	if err := s.openShards(ctx, count, concurrency); err != nil {
+		return err
+	}
+
+	if err := syncCommands(ctx, s.session, s.session.State.User.ID, s.devGuild, definitions(), s.log); err != nil {
+		closeShards(s.connectedShards())
+		return err
 	}
 
-	// Register slash commands and handler
-	if err := RegisterTimezoneCommand(s.session, s.db); err != nil {
-		return fmt.Errorf("failed to register slash command: %w", err)
+	s.jobs.start(ctx, s.log, s.db, s.cooldowns)
+
+	s.log.Info("bot is now running", slog.String("user_id", s.session.State.User.ID))
+	return nil
+}
+
+// Stop stops taking new events, waits for the ones being handled, stops the background jobs
+// and closes the gateway shards. Work still running when ctx is done is cancelled and ctx's
+// error is returned.
+func (s *DiscordServer) Stop(ctx context.Context) error {
+	err := s.handlers.shutdown(ctx)
+
+	s.jobs.stop()
+
+	if closeErr := closeShards(s.connectedShards()); err == nil {
+		err = closeErr
 	}
+	return err
+}
+
+// connectedShards returns the sessions of the shards connected so far
+func (s *DiscordServer) connectedShards() []*discordgo.Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.shards
+}
 
-	if err := RegisterConvertHandler(s.session, s.db); err != nil {
-		return fmt.Errorf("failed to register convert handler: %w", err)
+// CheckGateway reports an error unless every shard is connected and ready to receive events
+func (s *DiscordServer) CheckGateway(context.Context) error {
+	var failures []string
+	for _, dg := range s.connectedShards() {
+		dg.RLock()
+		ready := dg.DataReady
+		dg.RUnlock()
+
+		if !ready {
+			failures = append(failures, shardName(dg)+" is connecting")
+		}
 	}
 
-	fmt.Println("Bot is now running.")
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
 	return nil
 }
 
-// Stop closes the Discord session
-func (s *DiscordServer) Stop() error {
-	return s.session.Close()
+// CheckHeartbeat reports an error if a shard hasn't acknowledged a heartbeat for maxHeartbeatAge.
+// Shards still connecting are left to CheckGateway, they are slow rather than wedged.
+func (s *DiscordServer) CheckHeartbeat(context.Context) error {
+	var failures []string
+	for _, dg := range s.connectedShards() {
+		dg.RLock()
+		lastAck, lastSent := dg.LastHeartbeatAck, dg.LastHeartbeatSent
+		dg.RUnlock()
+
+		// discordgo starts the ACK clock when the session is created, heartbeats only start
+		// once the gateway said hello
+		if lastSent.IsZero() {
+			continue
+		}
+		if age := time.Since(lastAck); age > maxHeartbeatAge {
+			failures = append(failures, fmt.Sprintf("%s has no heartbeat ACK for %s", shardName(dg), age.Round(time.Second)))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// backgroundJobs periodically removes expired cooldowns and old conversion replies, and
+// reverts finished trips
+type backgroundJobs struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// start runs the jobs until ctx is cancelled or stop is called
+func (j *backgroundJobs) start(ctx context.Context, log *slog.Logger, db database.Store, cooldowns cooldown.Store) {
+	ctx, j.cancel = context.WithCancel(ctx)
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(jobInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := cooldowns.Sweep(ctx); err != nil {
+					log.Error("failed to sweep cooldowns", slog.Any("error", err))
+				}
+				if err := revertTravels(ctx, db); err != nil {
+					log.Error("failed to revert travels", slog.Any("error", err))
+				}
+				if _, err := db.DeleteConvertRepliesBefore(ctx, time.Now().Add(-convertReplyRetention)); err != nil {
+					log.Error("failed to remove old conversion replies", slog.Any("error", err))
+				}
+			}
+		}
+	}()
+}
+
+// stop stops the jobs and waits for the one running, if any
+func (j *backgroundJobs) stop() {
+	if j.cancel != nil {
+		j.cancel()
+		j.wg.Wait()
+	}
+}
+
+// respondEphemeral answers an interaction with a message only the invoking user can see
+func respondEphemeral(ctx context.Context, s Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		loggerFrom(ctx).Warn("failed to respond to interaction", slog.Any("error", err))
+	}
+}
+
+// interactionUserID returns the ID of the user who triggered an interaction, in guilds and DMs alike
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil {
+		return i.Member.User.ID
+	}
+	return i.User.ID
 }
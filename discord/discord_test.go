@@ -0,0 +1,150 @@
+package discord
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/cooldown"
+	"github.com/SHA65536/TimezoneBot/metrics"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDiscordServer_Checks(t *testing.T) {
+	ctx := context.Background()
+	srv, err := MakeDiscordServer("token", Sharding{}, "", nil, cooldown.NewMemory(1), slog.New(slog.NewTextHandler(io.Discard, nil)), metrics.New())
+	if err != nil {
+		t.Fatalf("MakeDiscordServer() unexpected error: %v", err)
+	}
+
+	// Not connected yet, but nothing is wedged either
+	if err := srv.CheckGateway(ctx); err == nil {
+		t.Error("CheckGateway() = nil before the gateway is ready")
+	}
+	if err := srv.CheckHeartbeat(ctx); err != nil {
+		t.Errorf("CheckHeartbeat() unexpected error before connecting: %v", err)
+	}
+
+	srv.session.DataReady = true
+	if err := srv.CheckGateway(ctx); err != nil {
+		t.Errorf("CheckGateway() unexpected error once ready: %v", err)
+	}
+
+	// The ACK clock starts when the session is created, a slow connection isn't wedged
+	srv.session.LastHeartbeatAck = time.Now().Add(-maxHeartbeatAge - time.Minute)
+	if err := srv.CheckHeartbeat(ctx); err != nil {
+		t.Errorf("CheckHeartbeat() unexpected error before the first heartbeat: %v", err)
+	}
+
+	srv.session.LastHeartbeatSent = time.Now()
+	if err := srv.CheckHeartbeat(ctx); err == nil {
+		t.Error("CheckHeartbeat() = nil without a recent heartbeat ACK")
+	}
+}
+
+func TestDiscordServer_ShardChecks(t *testing.T) {
+	ctx := context.Background()
+	srv, err := MakeDiscordServer("token", Sharding{Count: 4, IDs: []int{1, 3}}, "", nil, cooldown.NewMemory(1), slog.New(slog.NewTextHandler(io.Discard, nil)), metrics.New())
+	if err != nil {
+		t.Fatalf("MakeDiscordServer() unexpected error: %v", err)
+	}
+
+	shards := make([]*discordgo.Session, 2)
+	for n, id := range []int{1, 3} {
+		if shards[n], err = newGatewaySession("token"); err != nil {
+			t.Fatalf("newGatewaySession() unexpected error: %v", err)
+		}
+		shards[n].ShardID = id
+		shards[n].ShardCount = 4
+	}
+	srv.shards = shards
+
+	shards[0].DataReady = true
+	err = srv.CheckGateway(ctx)
+	if err == nil || !strings.Contains(err.Error(), "shard 3/4") || strings.Contains(err.Error(), "shard 1/4") {
+		t.Errorf("CheckGateway() = %v, want only shard 3/4 reported", err)
+	}
+
+	shards[1].DataReady = true
+	if err := srv.CheckGateway(ctx); err != nil {
+		t.Errorf("CheckGateway() unexpected error once every shard is ready: %v", err)
+	}
+
+	// Shards that haven't sent a heartbeat yet are connecting, not wedged
+	for _, dg := range shards {
+		dg.LastHeartbeatAck = time.Now().Add(-maxHeartbeatAge - time.Minute)
+	}
+	if err := srv.CheckHeartbeat(ctx); err != nil {
+		t.Errorf("CheckHeartbeat() = %v while connecting, want healthy", err)
+	}
+
+	shards[0].LastHeartbeatSent = time.Now()
+	err = srv.CheckHeartbeat(ctx)
+	if err == nil || !strings.Contains(err.Error(), "shard 1/4") || strings.Contains(err.Error(), "shard 3/4") {
+		t.Errorf("CheckHeartbeat() = %v, want only shard 1/4 reported", err)
+	}
+}
+
+func TestSharding(t *testing.T) {
+	tests := []struct {
+		name     string
+		sharding Sharding
+		count    int
+		want     []int
+		wantErr  bool
+	}{
+		{name: "automatic", sharding: Sharding{}, count: 3, want: []int{0, 1, 2}},
+		{name: "all of a fixed count", sharding: Sharding{Count: 2}, count: 2, want: []int{0, 1}},
+		{name: "some of a fixed count", sharding: Sharding{Count: 4, IDs: []int{2, 3}}, count: 4, want: []int{2, 3}},
+		{name: "IDs without count", sharding: Sharding{IDs: []int{0}}, wantErr: true},
+		{name: "ID out of range", sharding: Sharding{Count: 2, IDs: []int{2}}, wantErr: true},
+		{name: "negative count", sharding: Sharding{Count: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sharding.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := tt.sharding.ids(tt.count); !slices.Equal(got, tt.want) {
+				t.Errorf("ids(%d) = %v, want %v", tt.count, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseShardIDs(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: "3", want: []int{3}},
+		{input: "0-3,8", want: []int{0, 1, 2, 3, 8}},
+		{input: "5, 1-2, 2", want: []int{1, 2, 5}},
+		{input: "a", wantErr: true},
+		{input: "3-1", wantErr: true},
+		{input: "1-", wantErr: true},
+		{input: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseShardIDs(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseShardIDs(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseShardIDs(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package discord
+
+import (
+	"fmt"
+	"time"
+)
+
+// localInstant is the instant a wall clock time on a given day refers to in a timezone
+type localInstant struct {
+	Time time.Time
+	// Wall is the wall clock time that was asked for, as written in the message
+	Wall time.Time
+	// Skipped is set when the wall clock time doesn't exist because the clocks went forward,
+	// Time is then the instant the clocks would have shown it without the change
+	Skipped bool
+	// Repeated is set when the wall clock time happens twice because the clocks went back,
+	// Time is then the first occurrence and Later the second one
+	Repeated bool
+	Later    time.Time
+}
+
+// resolveWallClock finds the instant seconds after midnight refers to on the given date in loc.
+// Every UTC offset loc uses around that date is tried, which tells apart times that don't
+// exist on a spring-forward day from the ones that exist twice on a fall-back day.
+func resolveWallClock(year int, month time.Month, day int, seconds uint, loc *time.Location) localInstant {
+	wall := time.Date(year, month, day, 0, 0, int(seconds), 0, time.UTC)
+
+	// Transitions are far enough apart that the offsets a day either side cover every candidate
+	_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := wall.Add(24 * time.Hour).In(loc).Zone()
+	offsets := []int{before}
+	if after != before {
+		offsets = append(offsets, after)
+	}
+
+	var candidates []time.Time
+	for _, offset := range offsets {
+		instant := wall.Add(-time.Duration(offset) * time.Second)
+		if sameWallClock(instant.In(loc), wall) {
+			candidates = append(candidates, instant)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return localInstant{Time: wall.Add(-time.Duration(before) * time.Second).In(loc), Wall: wall, Skipped: true}
+	case 1:
+		return localInstant{Time: candidates[0].In(loc), Wall: wall}
+	}
+
+	first, second := candidates[0], candidates[1]
+	if second.Before(first) {
+		first, second = second, first
+	}
+	return localInstant{Time: first.In(loc), Wall: wall, Repeated: true, Later: second.In(loc)}
+}
+
+// sameWallClock reports whether a and b show the same date and time, ignoring their zones
+func sameWallClock(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	ah, amin, as := a.Clock()
+	bh, bmin, bs := b.Clock()
+	return ay == by && am == bm && ad == bd && ah == bh && amin == bmin && as == bs
+}
+
+// dstNote explains a converted time that was affected by a DST change, it is empty otherwise
+func dstNote(li localInstant, loc *time.Location) string {
+	switch {
+	case li.Skipped:
+		return fmt.Sprintf("%s doesn't exist in %s on that day because the clocks go forward, showing %s (%s) instead.",
+			li.Wall.Format("15:04"), loc, li.Time.Format("15:04"), zoneName(li.Time))
+	case li.Repeated:
+		return fmt.Sprintf("%s happens twice in %s on that day because the clocks go back, showing the first one (%s). The second one is %s.",
+			li.Wall.Format("15:04"), loc, zoneName(li.Time), formatTimestamp(li.Later.Unix(), "t"))
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveWallClock(t *testing.T) {
+	tests := []struct {
+		name     string
+		zone     string
+		date     time.Time
+		seconds  uint
+		want     time.Time
+		skipped  bool
+		repeated bool
+		later    time.Time
+	}{
+		{
+			name:    "ordinary day",
+			zone:    "Europe/London",
+			date:    time.Date(2026, 3, 28, 0, 0, 0, 0, time.UTC),
+			seconds: 3*3600 + 15*60,
+			want:    time.Date(2026, 3, 28, 3, 15, 0, 0, time.UTC),
+		},
+		{
+			name:    "London spring forward gap",
+			zone:    "Europe/London",
+			date:    time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC),
+			seconds: 1*3600 + 30*60,
+			want:    time.Date(2026, 3, 29, 1, 30, 0, 0, time.UTC),
+			skipped: true,
+		},
+		{
+			name:    "London spring forward after the gap",
+			zone:    "Europe/London",
+			date:    time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC),
+			seconds: 3 * 3600,
+			want:    time.Date(2026, 3, 29, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "London fall back overlap",
+			zone:     "Europe/London",
+			date:     time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+			seconds:  1*3600 + 30*60,
+			want:     time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC),
+			repeated: true,
+			later:    time.Date(2026, 10, 25, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			name:    "New York spring forward gap",
+			zone:    "America/New_York",
+			date:    time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC),
+			seconds: 2*3600 + 30*60,
+			want:    time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC),
+			skipped: true,
+		},
+		{
+			name:    "New York spring forward at 3:00",
+			zone:    "America/New_York",
+			date:    time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC),
+			seconds: 3 * 3600,
+			want:    time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "New York fall back overlap",
+			zone:     "America/New_York",
+			date:     time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+			seconds:  1*3600 + 30*60,
+			want:     time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC),
+			repeated: true,
+			later:    time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC),
+		},
+		{
+			name:    "Sydney spring forward gap in October",
+			zone:    "Australia/Sydney",
+			date:    time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC),
+			seconds: 2*3600 + 30*60,
+			want:    time.Date(2026, 10, 3, 16, 30, 0, 0, time.UTC),
+			skipped: true,
+		},
+		{
+			name:    "Lord Howe half hour gap",
+			zone:    "Australia/Lord_Howe",
+			date:    time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC),
+			seconds: 2*3600 + 15*60,
+			want:    time.Date(2026, 10, 3, 15, 45, 0, 0, time.UTC),
+			skipped: true,
+		},
+		{
+			name:    "zone without DST",
+			zone:    "Asia/Tokyo",
+			date:    time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC),
+			seconds: 1*3600 + 30*60,
+			want:    time.Date(2026, 3, 28, 16, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLoadLocation(t, tt.zone)
+			got := resolveWallClock(tt.date.Year(), tt.date.Month(), tt.date.Day(), tt.seconds, loc)
+
+			if !got.Time.Equal(tt.want) {
+				t.Errorf("resolveWallClock().Time = %v, want %v", got.Time.UTC(), tt.want)
+			}
+			if got.Skipped != tt.skipped || got.Repeated != tt.repeated {
+				t.Errorf("resolveWallClock() skipped = %v, repeated = %v, want %v, %v", got.Skipped, got.Repeated, tt.skipped, tt.repeated)
+			}
+			if tt.repeated && !got.Later.Equal(tt.later) {
+				t.Errorf("resolveWallClock().Later = %v, want %v", got.Later.UTC(), tt.later)
+			}
+		})
+	}
+}
+
+func TestDSTNote(t *testing.T) {
+	london := mustLoadLocation(t, "Europe/London")
+
+	gap := resolveWallClock(2026, 3, 29, 1*3600+30*60, london)
+	if got := dstNote(gap, london); !strings.Contains(got, "01:30 doesn't exist") || !strings.Contains(got, "02:30 (BST)") {
+		t.Errorf("dstNote() = %q for a skipped time", got)
+	}
+
+	overlap := resolveWallClock(2026, 10, 25, 1*3600+30*60, london)
+	if got := dstNote(overlap, london); !strings.Contains(got, "01:30 happens twice") || !strings.Contains(got, formatTimestamp(overlap.Later.Unix(), "t")) {
+		t.Errorf("dstNote() = %q for a repeated time", got)
+	}
+
+	if got := dstNote(resolveWallClock(2026, 7, 1, 12*3600, london), london); got != "" {
+		t.Errorf("dstNote() = %q on an ordinary day, want none", got)
+	}
+}
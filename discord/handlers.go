@@ -0,0 +1,143 @@
+package discord
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/SHA65536/TimezoneBot/cooldown"
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/SHA65536/TimezoneBot/metrics"
+	"github.com/SHA65536/TimezoneBot/parser"
+	"github.com/bwmarrin/discordgo"
+)
+
+// handlers reacts to Discord events. It only talks to Discord through session and
+// only reads the time from clock, so tests can feed it events without a gateway.
+type handlers struct {
+	session   Session
+	db        database.Store
+	cooldowns cooldown.Store
+	clock     Clock
+	log       *slog.Logger
+	metrics   *metrics.Metrics
+
+	parser  *parser.TimeParser
+	limiter *rateLimiter
+
+	// botID is the bot's own user ID, known once the gateway is ready
+	botID atomic.Pointer[string]
+
+	// ctx is cancelled to abort in-flight work when shutting down takes too long
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	closing  bool
+	inFlight sync.WaitGroup
+}
+
+// newHandlers creates the handlers of the bot user botID, which may be set later with setBotID
+func newHandlers(session Session, botID string, db database.Store, cooldowns cooldown.Store, clock Clock, log *slog.Logger, m *metrics.Metrics) *handlers {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &handlers{
+		session:   countingSession{Session: session, metrics: m},
+		db:        db,
+		cooldowns: cooldowns,
+		clock:     clock,
+		log:       log,
+		metrics:   m,
+		parser:    parser.NewTimeParserWithFormats(parser.Format24Hour, parser.Format12Hour, parser.FormatSimpleHour),
+		limiter:   newRateLimiter(autoConvertBurst, autoConvertInterval, clock),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	h.setBotID(botID)
+	return h
+}
+
+// setBotID records the bot's own user ID so its messages and reactions are ignored
+func (h *handlers) setBotID(id string) {
+	h.botID.Store(&id)
+}
+
+// isBot reports whether userID is the bot itself
+func (h *handlers) isBot(userID string) bool {
+	id := h.botID.Load()
+	return id != nil && *id != "" && *id == userID
+}
+
+// track registers an event being handled, it returns false once the handlers are shutting down
+func (h *handlers) track() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closing {
+		return false
+	}
+	h.inFlight.Add(1)
+	return true
+}
+
+// shutdown stops accepting events and waits for the ones being handled. If ctx is done first,
+// in-flight work is cancelled and ctx's error is returned.
+func (h *handlers) shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closing = true
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		h.cancel()
+		return nil
+	case <-ctx.Done():
+		h.cancel()
+		return ctx.Err()
+	}
+}
+
+// addTo registers the handlers on a Discord session, which should be done before it is opened
+// so no event is missed
+func (h *handlers) addTo(s *discordgo.Session) {
+	s.AddHandler(func(_ *discordgo.Session, r *discordgo.Ready) { h.setBotID(r.User.ID) })
+	s.AddHandler(func(_ *discordgo.Session, m *discordgo.MessageCreate) { tracked(h, m, h.onMessageCreate) })
+	s.AddHandler(func(_ *discordgo.Session, m *discordgo.MessageReactionAdd) { tracked(h, m, h.onReactionAdd) })
+	s.AddHandler(func(_ *discordgo.Session, m *discordgo.MessageUpdate) { tracked(h, m, h.onMessageUpdate) })
+	s.AddHandler(func(_ *discordgo.Session, i *discordgo.InteractionCreate) { tracked(h, i, h.onInteraction) })
+}
+
+// tracked runs handle for event unless the handlers are shutting down
+func tracked[E any](h *handlers, event E, handle func(E)) {
+	if !h.track() {
+		return
+	}
+	defer h.inFlight.Done()
+	handle(event)
+}
+
+// onInteraction routes slash commands, autocomplete requests and button clicks
+func (h *handlers) onInteraction(i *discordgo.InteractionCreate) {
+	ctx, span := h.interactionContext(i)
+	defer span.End()
+
+	switch i.Type {
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		h.handleAutocomplete(ctx, i)
+	case discordgo.InteractionApplicationCommand:
+		h.metrics.CommandReceived(i.ApplicationCommandData().Name)
+		cmd, ok := findCommand(i.ApplicationCommandData().Name)
+		if !ok {
+			loggerFrom(ctx).Warn("unknown slash command", slog.String("command", i.ApplicationCommandData().Name))
+			return
+		}
+		cmd.handle(h, ctx, i)
+	case discordgo.InteractionMessageComponent:
+		h.handleComponent(ctx, i)
+	}
+}
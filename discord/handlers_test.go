@@ -0,0 +1,300 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+var testNow = time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+
+// setTimezone runs /timezone for userID
+func (b *testBot) setTimezone(t *testing.T, userID, location string) {
+	t.Helper()
+	b.onInteraction(commandInteraction(userID, "timezone", stringOption("location", location)))
+	if got := b.session.lastResponse(t); got != "Timezone set to "+location {
+		t.Fatalf("/timezone response = %q, want confirmation", got)
+	}
+}
+
+func TestHandlers_TimezoneCommand(t *testing.T) {
+	bot := newTestBot(t, testNow)
+
+	bot.setTimezone(t, "1", "Europe/London")
+	if got, err := bot.store.GetTimezone(context.Background(), "1"); err != nil || got != "Europe/London" {
+		t.Errorf("GetTimezone() = %q, %v, want Europe/London", got, err)
+	}
+
+	bot.onInteraction(commandInteraction("1", "timezone", stringOption("location", "Mars/Olympus")))
+	if got := bot.session.lastResponse(t); got != "Invalid timezone selected." {
+		t.Errorf("/timezone response = %q for an unknown zone", got)
+	}
+}
+
+func TestHandlers_MessageCreateReacts(t *testing.T) {
+	bot := newTestBot(t, testNow)
+
+	// Authors without a timezone are ignored
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: userMessage("1", "1", "see you at 18:00", testNow)})
+	if len(bot.session.reactions) != 0 {
+		t.Fatalf("reactions = %v for an author without a timezone, want none", bot.session.reactions)
+	}
+
+	bot.setTimezone(t, "1", "Europe/London")
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: userMessage("2", "1", "see you at 18:00", testNow)})
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: userMessage("3", "1", "no time here", testNow)})
+	if want := []string{"2:⏰"}; fmt.Sprint(bot.session.reactions) != fmt.Sprint(want) {
+		t.Errorf("reactions = %v, want %v", bot.session.reactions, want)
+	}
+	if len(bot.session.sent) != 0 {
+		t.Errorf("sent %d messages in react mode, want none", len(bot.session.sent))
+	}
+}
+
+func TestHandlers_ReactionConverts(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "America/New_York")
+
+	// Posted on the evening of March 1st in New York, reacted to the next afternoon
+	posted := time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)
+	bot.session.post(userMessage("2", "1", "let's meet at 23:00", posted))
+
+	reaction := &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		UserID: "3", MessageID: "2", ChannelID: "20", GuildID: "10", Emoji: discordgo.Emoji{Name: "⏰"},
+	}}
+	bot.onReactionAdd(reaction)
+	bot.onReactionAdd(reaction)
+
+	if len(bot.session.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1 with the second reaction on cooldown", len(bot.session.sent))
+	}
+	reply := bot.session.sent[0]
+	want := time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC).Unix()
+	if reply.Content != formatTimestamp(want, "f") {
+		t.Errorf("reply = %q, want %q", reply.Content, formatTimestamp(want, "f"))
+	}
+	if reply.MessageReference == nil || reply.MessageReference.MessageID != "2" {
+		t.Errorf("reply references %+v, want message 2", reply.MessageReference)
+	}
+
+	saved, err := bot.store.GetConvertReply(context.Background(), "2")
+	if err != nil || saved.ReplyID != reply.ID {
+		t.Errorf("GetConvertReply() = %+v, %v, want reply %s", saved, err, reply.ID)
+	}
+}
+
+func TestHandlers_AutoThread(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+	err := bot.store.SetChannelMode(context.Background(), database.SetChannelModeParams{
+		ChannelID: "20", GuildID: "10", Mode: ChannelModeAutoThread,
+	})
+	if err != nil {
+		t.Fatalf("SetChannelMode() unexpected error: %v", err)
+	}
+
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: userMessage("2", "1", "call at 9pm", testNow)})
+
+	if len(bot.session.threads) != 1 || len(bot.session.sent) != 1 {
+		t.Fatalf("started %d threads and sent %d messages, want 1 each", len(bot.session.threads), len(bot.session.sent))
+	}
+	if reply := bot.session.sent[0]; reply.ChannelID != bot.session.threads[0] || reply.MessageReference != nil {
+		t.Errorf("reply in channel %s referencing %+v, want a plain message in the thread", reply.ChannelID, reply.MessageReference)
+	}
+	if len(bot.session.reactions) != 0 {
+		t.Errorf("reactions = %v in auto-thread mode, want none", bot.session.reactions)
+	}
+}
+
+func TestHandlers_MessageUpdate(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+	bot.session.post(userMessage("2", "1", "at 18:00", testNow))
+	bot.onReactionAdd(&discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		UserID: "3", MessageID: "2", ChannelID: "20", GuildID: "10", Emoji: discordgo.Emoji{Name: "⏰"},
+	}})
+	reply := bot.session.sent[0]
+
+	// A new time edits the existing reply
+	bot.session.post(userMessage("2", "1", "at 19:00", testNow))
+	bot.onMessageUpdate(&discordgo.MessageUpdate{Message: userMessage("2", "1", "at 19:00", testNow)})
+	if len(bot.session.edited) != 1 || len(bot.session.sent) != 1 {
+		t.Fatalf("edited %d and sent %d messages, want the reply edited in place", len(bot.session.edited), len(bot.session.sent))
+	}
+	want := time.Date(2026, 3, 2, 19, 0, 0, 0, time.UTC).Unix()
+	if got := *bot.session.edited[0].Content; got != formatTimestamp(want, "t") {
+		t.Errorf("edited reply = %q, want %q", got, formatTimestamp(want, "t"))
+	}
+
+	// Updates without content, like embeds loading, leave it alone
+	bot.onMessageUpdate(&discordgo.MessageUpdate{Message: &discordgo.Message{ID: "2", ChannelID: "20", GuildID: "10"}})
+	if len(bot.session.edited) != 1 || len(bot.session.deleted) != 0 {
+		t.Errorf("edited %d and deleted %d messages for an update without content", len(bot.session.edited), len(bot.session.deleted))
+	}
+
+	// Removing the time deletes it
+	bot.session.post(userMessage("2", "1", "never mind", testNow))
+	bot.onMessageUpdate(&discordgo.MessageUpdate{Message: userMessage("2", "1", "never mind", testNow)})
+	if fmt.Sprint(bot.session.deleted) != fmt.Sprint([]string{reply.ID}) {
+		t.Errorf("deleted = %v, want the reply %s", bot.session.deleted, reply.ID)
+	}
+}
+
+func TestHandlers_LocalButton(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+	bot.setTimezone(t, "2", "Asia/Tokyo")
+
+	bot.onInteraction(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:    discordgo.InteractionMessageComponent,
+		GuildID: "10",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "2"}},
+		Data:    discordgo.MessageComponentInteractionData{CustomID: localButton(testNow.Unix(), "1", testNow).CustomID},
+	}})
+
+	if got := bot.session.lastResponse(t); !strings.Contains(got, "March 3, 2026 at 12:00 AM") {
+		t.Errorf("local button response = %q, want the time in Tokyo", got)
+	}
+}
+
+func TestHandlers_Travel(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+
+	bot.onInteraction(commandInteraction("1", "travel", stringOption("zone", "Asia/Tokyo"), stringOption("until", "2020-01-01")))
+	if got := bot.session.lastResponse(t); !strings.HasPrefix(got, "The date must be in the future") {
+		t.Errorf("/travel response = %q for a past date", got)
+	}
+
+	bot.onInteraction(commandInteraction("1", "travel", stringOption("zone", "Asia/Tokyo"), stringOption("until", "2026-03-10")))
+	if got := bot.session.lastResponse(t); !strings.HasPrefix(got, "Timezone set to Asia/Tokyo") {
+		t.Fatalf("/travel response = %q", got)
+	}
+	if got, _ := bot.store.GetTimezone(context.Background(), "1"); got != "Asia/Tokyo" {
+		t.Errorf("GetTimezone() = %q while travelling, want Asia/Tokyo", got)
+	}
+
+	// The revert job runs against the database clock, so move the trip into the past
+	err := bot.store.SetTravel(context.Background(), database.SetTravelParams{
+		UserID: "1", HomeTimezone: "Europe/London", ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("SetTravel() unexpected error: %v", err)
+	}
+	if err := revertTravels(context.Background(), bot.store); err != nil {
+		t.Fatalf("revertTravels() unexpected error: %v", err)
+	}
+	if got, _ := bot.store.GetTimezone(context.Background(), "1"); got != "Europe/London" {
+		t.Errorf("GetTimezone() = %q after the trip, want Europe/London", got)
+	}
+}
+
+func TestHandlers_TravelWithServerTimezone(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+	bot.onInteraction(commandInteraction("1", "timezone", stringOption("location", "America/New_York"), stringOption("scope", settingsScopeGuild)))
+
+	bot.onInteraction(commandInteraction("1", "travel", stringOption("zone", "Asia/Tokyo"), stringOption("until", "2026-03-10")))
+	if got := bot.session.lastResponse(t); !strings.Contains(got, "Your timezone on this server stays America/New_York") {
+		t.Errorf("/travel response = %q, want the server timezone called out", got)
+	}
+	// The server timezone takes precedence over the trip
+	if got, err := resolveTimezone(context.Background(), bot.store, "10", "1"); err != nil || got != "America/New_York" {
+		t.Errorf("resolveTimezone() = %q, %v on the server, want America/New_York", got, err)
+	}
+}
+
+func TestParseLocalButton(t *testing.T) {
+	arg := strings.TrimPrefix(localButton(100, "1", testNow).CustomID, localButtonID+":")
+	if ts, authorID, posted, ok := parseLocalButton(arg); !ok || ts != 100 || authorID != "1" || !posted.Equal(testNow) {
+		t.Errorf("parseLocalButton(%q) = %d, %q, %v, %v", arg, ts, authorID, posted, ok)
+	}
+	for _, arg := range []string{"", "100:1", "x:1:2", "100:1:x", "100:1:2:3"} {
+		if _, _, _, ok := parseLocalButton(arg); ok {
+			t.Errorf("parseLocalButton(%q) ok for a malformed button", arg)
+		}
+	}
+}
+
+func TestHandlers_Shutdown(t *testing.T) {
+	bot := newTestBot(t, testNow)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go tracked(bot.handlers, "event", func(string) {
+		close(started)
+		<-release
+	})
+	<-started
+
+	// In-flight events hold up shutdown until the deadline, then get cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bot.shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("shutdown() error = %v with an event in flight, want DeadlineExceeded", err)
+	}
+	if bot.ctx.Err() == nil {
+		t.Errorf("handler context not cancelled after a timed out shutdown")
+	}
+	close(release)
+
+	// Events arriving after shutdown are dropped
+	handled := false
+	tracked(bot.handlers, "event", func(string) { handled = true })
+	if handled {
+		t.Errorf("event handled after shutdown")
+	}
+	if err := bot.shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v once idle, want nil", err)
+	}
+}
+
+func TestHandlers_Metrics(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+
+	msg := userMessage("2", "1", "see you at 6pm", testNow)
+	bot.session.post(msg)
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: msg})
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: userMessage("3", "1", "no time here", testNow)})
+
+	// Times in channels where the bot is off still count as matched
+	err := bot.store.SetChannelMode(context.Background(), database.SetChannelModeParams{ChannelID: "21", GuildID: "10", Mode: ChannelModeOff})
+	if err != nil {
+		t.Fatalf("SetChannelMode() unexpected error: %v", err)
+	}
+	offMsg := userMessage("4", "1", "see you at 7pm", testNow)
+	offMsg.ChannelID = "21"
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: offMsg})
+
+	reaction := func(messageID string) *discordgo.MessageReactionAdd {
+		return &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+			UserID: "3", MessageID: messageID, ChannelID: "20", GuildID: "10", Emoji: discordgo.Emoji{Name: "⏰"},
+		}}
+	}
+	bot.onReactionAdd(reaction("2"))
+	bot.onReactionAdd(reaction("2"))
+	bot.onReactionAdd(reaction("404"))
+
+	rec := httptest.NewRecorder()
+	bot.metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"timezonebot_messages_scanned_total 3",
+		`timezonebot_time_matches_total{format="12-hour with am/pm"} 2`,
+		"timezonebot_reactions_added_total 1",
+		"timezonebot_conversions_sent_total 1",
+		"timezonebot_cooldown_suppressions_total 1",
+		`timezonebot_commands_total{command="timezone"} 1`,
+		`timezonebot_discord_api_errors_total{call="ChannelMessage"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics are missing %q", want)
+		}
+	}
+}
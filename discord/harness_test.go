@@ -0,0 +1,240 @@
+package discord
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/cooldown"
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/SHA65536/TimezoneBot/metrics"
+	"github.com/bwmarrin/discordgo"
+)
+
+const testBotID = "100"
+
+// fakeSession is a Session that keeps messages in memory and records what the handlers did
+type fakeSession struct {
+	mu        sync.Mutex
+	nextID    int
+	messages  map[string]*discordgo.Message
+	sent      []*discordgo.Message
+	edited    []*discordgo.MessageEdit
+	deleted   []string
+	reactions []string
+	threads   []string
+	responses []*discordgo.InteractionResponse
+	followups []*discordgo.WebhookEdit
+	files     []*discordgo.File
+	// dmClosed makes opening a DM fail, as for users who don't accept DMs
+	dmClosed bool
+}
+
+func newFakeSession() *fakeSession {
+	return &fakeSession{nextID: 1000, messages: map[string]*discordgo.Message{}}
+}
+
+// post stores a message as if a user had sent it, so the handlers can fetch it
+func (f *fakeSession) post(msg *discordgo.Message) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages[msg.ID] = msg
+}
+
+func (f *fakeSession) ChannelMessage(channelID, messageID string, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	msg, ok := f.messages[messageID]
+	if !ok || msg.ChannelID != channelID {
+		return nil, fmt.Errorf("unknown message %s", messageID)
+	}
+	// Like the REST API, fetched messages don't say which guild they belong to
+	fetched := *msg
+	fetched.GuildID = ""
+	return &fetched, nil
+}
+
+func (f *fakeSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	msg := &discordgo.Message{
+		ID:               fmt.Sprint(f.nextID),
+		ChannelID:        channelID,
+		Content:          data.Content,
+		Components:       data.Components,
+		MessageReference: data.Reference,
+		Author:           &discordgo.User{ID: testBotID},
+	}
+	f.messages[msg.ID] = msg
+	f.sent = append(f.sent, msg)
+	f.files = append(f.files, data.Files...)
+	return msg, nil
+}
+
+func (f *fakeSession) ChannelMessageEditComplex(m *discordgo.MessageEdit, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	msg, ok := f.messages[m.ID]
+	if !ok {
+		return nil, fmt.Errorf("unknown message %s", m.ID)
+	}
+	if m.Content != nil {
+		msg.Content = *m.Content
+	}
+	f.edited = append(f.edited, m)
+	return msg, nil
+}
+
+func (f *fakeSession) ChannelMessageDelete(_, messageID string, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.messages, messageID)
+	f.deleted = append(f.deleted, messageID)
+	return nil
+}
+
+func (f *fakeSession) MessageReactionAdd(_, messageID, emojiID string, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reactions = append(f.reactions, messageID+":"+emojiID)
+	return nil
+}
+
+func (f *fakeSession) MessageThreadStartComplex(_, messageID string, data *discordgo.ThreadStart, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	thread := &discordgo.Channel{ID: "thread-" + messageID, Name: data.Name}
+	f.threads = append(f.threads, thread.ID)
+	return thread, nil
+}
+
+func (f *fakeSession) InteractionRespond(_ *discordgo.Interaction, resp *discordgo.InteractionResponse, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, resp)
+	return nil
+}
+
+func (f *fakeSession) InteractionResponseEdit(_ *discordgo.Interaction, edit *discordgo.WebhookEdit, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.followups = append(f.followups, edit)
+	return &discordgo.Message{}, nil
+}
+
+func (f *fakeSession) UserChannelCreate(recipientID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	if f.dmClosed {
+		return nil, fmt.Errorf("cannot send messages to user %s", recipientID)
+	}
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+// lastResponse returns the content of the latest interaction response
+func (f *fakeSession) lastResponse(t *testing.T) string {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.responses) == 0 {
+		t.Fatalf("no interaction response was sent")
+	}
+	return f.responses[len(f.responses)-1].Data.Content
+}
+
+// fakeClock is a Clock that only moves when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// testBot bundles handlers wired to fakes with a migrated SQLite store
+type testBot struct {
+	*handlers
+	session *fakeSession
+	clock   *fakeClock
+	store   database.Store
+	metrics *metrics.Metrics
+}
+
+func newTestBot(t *testing.T, now time.Time) *testBot {
+	t.Helper()
+
+	cfg := database.DatabaseConfig{
+		Driver: database.DriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "test.db"),
+	}
+	if err := database.RunMigrations(cfg); err != nil {
+		t.Fatalf("RunMigrations() unexpected error: %v", err)
+	}
+	store, err := database.MakeDatabase(cfg)
+	if err != nil {
+		t.Fatalf("MakeDatabase() unexpected error: %v", err)
+	}
+	t.Cleanup(store.Close)
+
+	session := newFakeSession()
+	clock := &fakeClock{now: now}
+	m := metrics.New()
+	return &testBot{
+		handlers: newHandlers(session, testBotID, store, cooldown.NewMemory(100), clock, slog.New(slog.NewTextHandler(io.Discard, nil)), m),
+		session:  session,
+		clock:    clock,
+		store:    store,
+		metrics:  m,
+	}
+}
+
+// userMessage builds a guild message sent by userID at posted
+func userMessage(id, userID, content string, posted time.Time) *discordgo.Message {
+	return &discordgo.Message{
+		ID:        id,
+		ChannelID: "20",
+		GuildID:   "10",
+		Content:   content,
+		Timestamp: posted,
+		Author:    &discordgo.User{ID: userID},
+	}
+}
+
+// commandInteraction builds a slash command invoked by userID in the test guild
+func commandInteraction(userID, name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   "10",
+		ChannelID: "20",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: userID}},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name:    name,
+			Options: options,
+		},
+	}}
+}
+
+// stringOption builds a string option of a slash command
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Type:  discordgo.ApplicationCommandOptionString,
+		Value: value,
+	}
+}
+
+// subcommandOption builds a subcommand of a slash command with its options
+func subcommandOption(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:    name,
+		Type:    discordgo.ApplicationCommandOptionSubCommand,
+		Options: options,
+	}
+}
+
+// withPermissions gives the member invoking i the permissions bitset
+func withPermissions(i *discordgo.InteractionCreate, permissions int64) *discordgo.InteractionCreate {
+	i.Member.Permissions = permissions
+	return i
+}
@@ -0,0 +1,289 @@
+package discord
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/cooldown"
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/SHA65536/TimezoneBot/metrics"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxInteractionSize bounds the body of an interaction request
+	maxInteractionSize = 1 << 20
+
+	// deferAfter is how long a handler has to answer within the HTTP response. Discord gives
+	// up after 3 seconds, slower handlers get a deferred response and edit it once done.
+	deferAfter = 2500 * time.Millisecond
+)
+
+// InteractionServer serves slash commands, autocomplete and buttons delivered by Discord to
+// an HTTP interactions endpoint, without a gateway connection. Reaction and message features
+// need the gateway and are only served by DiscordServer.
+type InteractionServer struct {
+	session   *discordgo.Session
+	publicKey ed25519.PublicKey
+	devGuild  string
+	db        database.Store
+	cooldowns cooldown.Store
+	handlers  *handlers
+	responder *httpResponder
+	log       *slog.Logger
+	jobs      backgroundJobs
+}
+
+// MakeInteractionServer creates an InteractionServer verifying requests with the application's
+// hex encoded publicKey, which logs to log and records its activity in m. Slash commands are
+// registered to the guild devGuild alone when it is set.
+func MakeInteractionServer(token, publicKey, devGuild string, db database.Store, cooldowns cooldown.Store, log *slog.Logger, m *metrics.Metrics) (*InteractionServer, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("public key must be the application's hex encoded Ed25519 key")
+	}
+
+	dg, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Discord session: %w", err)
+	}
+	dg.Client.Transport = tracingTransport{base: http.DefaultTransport}
+
+	responder := newHTTPResponder(dg, deferAfter)
+	return &InteractionServer{
+		session:   dg,
+		publicKey: key,
+		devGuild:  devGuild,
+		db:        db,
+		cooldowns: cooldowns,
+		handlers:  newHandlers(responder, "", db, cooldowns, systemClock{}, log, m),
+		responder: responder,
+		log:       log,
+	}, nil
+}
+
+// Start syncs the slash commands and starts the background jobs, which run until ctx is
+// cancelled or Stop is called. Interactions can be served before it is called.
+func (s *InteractionServer) Start(ctx context.Context) error {
+	app, err := s.session.Application("@me")
+	if err != nil {
+		return fmt.Errorf("error fetching application: %w", err)
+	}
+	s.handlers.setBotID(app.ID)
+
+	if err := syncCommands(ctx, s.session, app.ID, s.devGuild, definitions(), s.log); err != nil {
+		return err
+	}
+
+	s.jobs.start(ctx, s.log, s.db, s.cooldowns)
+	s.log.Info("serving interactions over HTTP", slog.String("application_id", app.ID))
+	return nil
+}
+
+// Stop stops taking new interactions, waits for the ones being handled and stops the
+// background jobs. Work still running when ctx is done is cancelled and ctx's error is returned.
+func (s *InteractionServer) Stop(ctx context.Context) error {
+	err := s.handlers.shutdown(ctx)
+	s.jobs.stop()
+	return err
+}
+
+// ServeHTTP answers an interaction request once its signature has been verified
+func (s *InteractionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxInteractionSize)
+	if !discordgo.VerifyInteraction(r, s.publicKey) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var i discordgo.InteractionCreate
+	if err := json.NewDecoder(r.Body).Decode(&i); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	// Discord pings the endpoint when it is configured and from time to time after that
+	if i.Type == discordgo.InteractionPing {
+		writeInteractionResponse(w, &discordgo.InteractionResponse{Type: discordgo.InteractionResponsePong})
+		return
+	}
+
+	resp, written := s.responder.dispatch(&i, func() { tracked(s.handlers, &i, s.handlers.onInteraction) })
+	// Edits of a deferred response wait for it, so they reach Discord after the deferral did
+	defer written()
+	if resp == nil {
+		// The handler gave up without answering, or the server is shutting down
+		http.Error(w, "interaction was not handled", http.StatusServiceUnavailable)
+		return
+	}
+	writeInteractionResponse(w, resp)
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		s.log.Debug("failed to flush interaction response", slog.Any("error", err))
+	}
+}
+
+func writeInteractionResponse(w http.ResponseWriter, resp *discordgo.InteractionResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// httpResponder is the Session of handlers serving HTTP interactions. It sends their
+// interaction responses as the body of the HTTP response instead of calling the API,
+// and turns them into edits of a deferred response when they come too late.
+type httpResponder struct {
+	Session
+	deferAfter time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingInteraction
+}
+
+// pendingInteraction is an interaction whose HTTP request may still be waiting for a response
+type pendingInteraction struct {
+	response chan *discordgo.InteractionResponse
+	// answered is set once a response was handed to the HTTP request, deferred if that response
+	// was a deferral that later responses should edit
+	answered bool
+	deferred bool
+	// written is closed once the HTTP response was sent, edits of a deferral wait for it
+	written chan struct{}
+}
+
+func newHTTPResponder(s Session, deferAfter time.Duration) *httpResponder {
+	return &httpResponder{
+		Session:    s,
+		deferAfter: deferAfter,
+		pending:    map[string]*pendingInteraction{},
+	}
+}
+
+// dispatch runs handle for i and returns the response to send back over HTTP: the one the
+// handler gives if it does so in time, a deferral otherwise. It returns nil if handle
+// finishes without responding. The returned written func must be called once the response
+// was sent, until then edits of a deferral are held back.
+func (r *httpResponder) dispatch(i *discordgo.InteractionCreate, handle func()) (resp *discordgo.InteractionResponse, written func()) {
+	p := &pendingInteraction{
+		response: make(chan *discordgo.InteractionResponse, 1),
+		written:  make(chan struct{}),
+	}
+	r.mu.Lock()
+	r.pending[i.ID] = p
+	r.mu.Unlock()
+
+	return r.wait(i, p, handle), sync.OnceFunc(func() { close(p.written) })
+}
+
+// wait runs handle and returns the response for the HTTP request of i
+func (r *httpResponder) wait(i *discordgo.InteractionCreate, p *pendingInteraction, handle func()) *discordgo.InteractionResponse {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer r.forget(i.ID)
+		handle()
+	}()
+
+	timer := time.NewTimer(r.deferAfter)
+	defer timer.Stop()
+
+	select {
+	case resp := <-p.response:
+		return resp
+	case <-done:
+		select {
+		case resp := <-p.response:
+			return resp
+		default:
+			return nil
+		}
+	case <-timer.C:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if p.answered {
+			return <-p.response
+		}
+		p.answered = true
+		if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+			// Autocomplete can't be deferred, offer no choices rather than an error
+			return &discordgo.InteractionResponse{
+				Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+				Data: &discordgo.InteractionResponseData{Choices: []*discordgo.ApplicationCommandOptionChoice{}},
+			}
+		}
+		p.deferred = true
+		return deferredResponse()
+	}
+}
+
+func (r *httpResponder) forget(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, id)
+}
+
+// deferredResponse acknowledges an interaction whose answer comes later. Every answer of
+// the bot is only shown to the invoking user, so the deferral is too.
+func deferredResponse() *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}
+}
+
+// InteractionRespond hands resp to the HTTP request of the interaction, or edits the deferred
+// response if the request was already answered with one. Interactions not received over HTTP
+// are answered through the API.
+func (r *httpResponder) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	r.mu.Lock()
+	p, ok := r.pending[interaction.ID]
+	if !ok {
+		r.mu.Unlock()
+		return r.Session.InteractionRespond(interaction, resp, options...)
+	}
+
+	switch {
+	case p.deferred:
+	case p.answered:
+		r.mu.Unlock()
+		return errors.New("interaction was already answered")
+	case resp.Data != nil && len(resp.Data.Files) > 0:
+		// Files can't be sent in the HTTP response, defer and attach them with an edit
+		p.answered = true
+		p.deferred = true
+		p.response <- deferredResponse()
+	default:
+		p.answered = true
+		p.response <- resp
+		r.mu.Unlock()
+		return nil
+	}
+	r.mu.Unlock()
+
+	// Discord rejects edits of an interaction it hasn't seen acknowledged yet
+	<-p.written
+
+	edit := &discordgo.WebhookEdit{}
+	if resp.Data != nil {
+		edit.Content = &resp.Data.Content
+		edit.Files = resp.Data.Files
+		edit.AllowedMentions = resp.Data.AllowedMentions
+		if resp.Data.Components != nil {
+			edit.Components = &resp.Data.Components
+		}
+	}
+	_, err := r.Session.InteractionResponseEdit(interaction, edit, options...)
+	return err
+}
@@ -0,0 +1,178 @@
+package discord
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newTestInteractionServer serves the test bot's handlers over HTTP, verifying requests
+// with the public key of the returned private key
+func newTestInteractionServer(t *testing.T) (*InteractionServer, ed25519.PrivateKey, *testBot) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() unexpected error: %v", err)
+	}
+
+	bot := newTestBot(t, testNow)
+	responder := newHTTPResponder(bot.session, time.Second)
+	bot.handlers.session = countingSession{Session: responder, metrics: bot.metrics}
+	return &InteractionServer{
+		publicKey: publicKey,
+		handlers:  bot.handlers,
+		responder: responder,
+		log:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, privateKey, bot
+}
+
+// postInteraction sends i to srv signed with key and returns the recorded response
+func postInteraction(t *testing.T, srv *InteractionServer, key ed25519.PrivateKey, i *discordgo.Interaction) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(i)
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error: %v", err)
+	}
+
+	timestamp := "1760000000"
+	req := httptest.NewRequest(http.MethodPost, "/interactions", bytes.NewReader(body))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, append([]byte(timestamp), body...))))
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeResponse decodes the interaction response in rec
+func decodeResponse(t *testing.T, rec *httptest.ResponseRecorder) discordgo.InteractionResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d %q, want 200", rec.Code, rec.Body.String())
+	}
+	var resp discordgo.InteractionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return resp
+}
+
+func TestInteractionServer_Signature(t *testing.T) {
+	srv, _, _ := newTestInteractionServer(t)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+
+	ping := &discordgo.Interaction{Type: discordgo.InteractionPing}
+	if rec := postInteraction(t, srv, otherKey, ping); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d for a request signed with another key, want 401", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/interactions", bytes.NewReader([]byte(`{"type":1}`)))
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d for an unsigned request, want 401", rec.Code)
+	}
+}
+
+func TestInteractionServer_Ping(t *testing.T) {
+	srv, key, _ := newTestInteractionServer(t)
+
+	resp := decodeResponse(t, postInteraction(t, srv, key, &discordgo.Interaction{Type: discordgo.InteractionPing}))
+	if resp.Type != discordgo.InteractionResponsePong {
+		t.Errorf("response type = %v, want pong", resp.Type)
+	}
+}
+
+func TestInteractionServer_Command(t *testing.T) {
+	srv, key, bot := newTestInteractionServer(t)
+
+	i := commandInteraction("1", "timezone", stringOption("location", "Europe/London")).Interaction
+	i.ID = "500"
+	resp := decodeResponse(t, postInteraction(t, srv, key, i))
+
+	if resp.Type != discordgo.InteractionResponseChannelMessageWithSource || resp.Data.Content != "Timezone set to Europe/London" {
+		t.Errorf("response = %v %+v, want the confirmation", resp.Type, resp.Data)
+	}
+	if len(bot.session.responses) != 0 {
+		t.Errorf("responded %d times through the API, want only the HTTP response", len(bot.session.responses))
+	}
+}
+
+func TestHTTPResponder_Defer(t *testing.T) {
+	session := newFakeSession()
+	responder := newHTTPResponder(session, 10*time.Millisecond)
+	i := commandInteraction("1", "timezone")
+	i.ID = "500"
+
+	handled := make(chan error, 1)
+	resp, written := responder.dispatch(i, func() {
+		time.Sleep(50 * time.Millisecond)
+		handled <- responder.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "done"},
+		})
+	})
+
+	if resp == nil || resp.Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Fatalf("dispatch() = %+v for a slow handler, want a deferral", resp)
+	}
+	written()
+	if err := <-handled; err != nil {
+		t.Fatalf("InteractionRespond() unexpected error: %v", err)
+	}
+	if len(session.followups) != 1 || *session.followups[0].Content != "done" {
+		t.Errorf("followups = %+v, want the late response as an edit", session.followups)
+	}
+}
+
+func TestHTTPResponder_Files(t *testing.T) {
+	session := newFakeSession()
+	responder := newHTTPResponder(session, time.Second)
+	i := commandInteraction("1", "timezone")
+	i.ID = "500"
+
+	file := &discordgo.File{Name: "event.ics", Reader: bytes.NewReader(nil)}
+	handled := make(chan error, 1)
+	resp, written := responder.dispatch(i, func() {
+		handled <- responder.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "event", Files: []*discordgo.File{file}},
+		})
+	})
+
+	if resp == nil || resp.Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Fatalf("dispatch() = %+v for a response with files, want a deferral", resp)
+	}
+	select {
+	case err := <-handled:
+		t.Fatalf("InteractionRespond() = %v before the deferral was written, want it to wait", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	written()
+	if err := <-handled; err != nil {
+		t.Fatalf("InteractionRespond() unexpected error: %v", err)
+	}
+	if len(session.followups) != 1 || len(session.followups[0].Files) != 1 {
+		t.Errorf("followups = %+v, want the files attached by an edit", session.followups)
+	}
+}
+
+func TestHTTPResponder_Unanswered(t *testing.T) {
+	responder := newHTTPResponder(newFakeSession(), time.Second)
+	i := commandInteraction("1", "timezone")
+	i.ID = "500"
+
+	if resp, _ := responder.dispatch(i, func() {}); resp != nil {
+		t.Errorf("dispatch() = %+v for a handler that didn't respond, want nil", resp)
+	}
+}
@@ -0,0 +1,156 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+const localButtonID = "convert_local"
+
+// localButton returns the "Show in my zone" button for a converted instant, found in a
+// message of authorID sent at posted
+func localButton(unixTimestamp int64, authorID string, posted time.Time) discordgo.Button {
+	return discordgo.Button{
+		Label:    "Show in my zone",
+		Style:    discordgo.SecondaryButton,
+		Emoji:    discordgo.ComponentEmoji{Name: "🌍"},
+		CustomID: fmt.Sprintf("%s:%d:%s:%d", localButtonID, unixTimestamp, authorID, posted.Unix()),
+	}
+}
+
+// parseLocalButton reads the arguments of a localButton
+func parseLocalButton(arg string) (unixTimestamp int64, authorID string, posted time.Time, ok bool) {
+	parts := strings.Split(arg, ":")
+	if len(parts) != 3 {
+		return 0, "", time.Time{}, false
+	}
+
+	unixTimestamp, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", time.Time{}, false
+	}
+	postedUnix, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, "", time.Time{}, false
+	}
+	return unixTimestamp, parts[1], time.Unix(postedUnix, 0), true
+}
+
+// handleLocalButton answers privately with the instant spelled out in the clicker's timezone
+func (h *handlers) handleLocalButton(ctx context.Context, i *discordgo.InteractionCreate, arg string) {
+	unixTimestamp, authorID, posted, ok := parseLocalButton(arg)
+	if !ok {
+		loggerFrom(ctx).Warn("malformed local time button", slog.String("custom_id", i.MessageComponentData().CustomID))
+		return
+	}
+
+	userTimezone, err := resolveTimezone(ctx, h.db, i.GuildID, interactionUserID(i))
+	if errors.Is(err, database.ErrNoRows) {
+		respondEphemeral(ctx, h.session, i, "You haven't set a timezone yet, use /timezone first.")
+		return
+	} else if err != nil {
+		loggerFrom(ctx).Error("failed to look up viewer timezone", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to look up your timezone.")
+		return
+	}
+	userLoc, err := time.LoadLocation(userTimezone)
+	if err != nil {
+		respondEphemeral(ctx, h.session, i, "Your saved timezone is invalid, set it again with /timezone.")
+		return
+	}
+
+	// The author's timezone when posting, like the conversion it goes with
+	authorTimezone, err := resolveTimezoneAt(ctx, h.db, i.GuildID, authorID, posted)
+	if errors.Is(err, database.ErrNoRows) {
+		respondEphemeral(ctx, h.session, i, "The author's timezone is no longer available.")
+		return
+	} else if err != nil {
+		loggerFrom(ctx).Error("failed to look up author timezone", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to look up the author's timezone.")
+		return
+	}
+	authorLoc, err := time.LoadLocation(authorTimezone)
+	if err != nil {
+		respondEphemeral(ctx, h.session, i, "The author's timezone is no longer available.")
+		return
+	}
+
+	respondEphemeral(ctx, h.session, i, describeForViewer(time.Unix(unixTimestamp, 0), authorLoc, userLoc))
+}
+
+// describeForViewer spells out an instant in the viewer's timezone, along with
+// how far the viewer is from the author and which day it falls on for them
+func describeForViewer(instant time.Time, authorLoc, viewerLoc *time.Location) string {
+	authorTime := instant.In(authorLoc)
+	viewerTime := instant.In(viewerLoc)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s** (%s, %s)", viewerTime.Format("Monday, January 2, 2006 at 3:04 PM"), viewerLoc, zoneName(viewerTime))
+	switch dayDifference(authorTime, viewerTime) {
+	case 1:
+		sb.WriteString(", tomorrow for you")
+	case -1:
+		sb.WriteString(", yesterday for you")
+	}
+	sb.WriteString(".\n")
+
+	_, authorOffset := authorTime.Zone()
+	_, viewerOffset := viewerTime.Zone()
+	diff := time.Duration(viewerOffset-authorOffset) * time.Second
+	switch {
+	case diff > 0:
+		fmt.Fprintf(&sb, "You are %s ahead of the author (%s).", formatOffset(diff), authorLoc)
+	case diff < 0:
+		fmt.Fprintf(&sb, "You are %s behind the author (%s).", formatOffset(-diff), authorLoc)
+	default:
+		fmt.Fprintf(&sb, "You share the author's UTC offset (%s).", authorLoc)
+	}
+
+	return sb.String()
+}
+
+// dayDifference returns how many calendar days the viewer's date is ahead of the author's
+func dayDifference(authorTime, viewerTime time.Time) int {
+	authorDay := time.Date(authorTime.Year(), authorTime.Month(), authorTime.Day(), 0, 0, 0, 0, time.UTC)
+	viewerDay := time.Date(viewerTime.Year(), viewerTime.Month(), viewerTime.Day(), 0, 0, 0, 0, time.UTC)
+	return int(viewerDay.Sub(authorDay).Hours() / 24)
+}
+
+// formatOffset formats an offset difference as hours and minutes, e.g. "5 hours 30 minutes"
+func formatOffset(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+
+	var parts []string
+	if hours > 0 {
+		parts = append(parts, plural(hours, "hour"))
+	}
+	if minutes > 0 {
+		parts = append(parts, plural(minutes, "minute"))
+	}
+	return strings.Join(parts, " ")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// zoneName returns the zone abbreviation, or the numeric offset when the zone has none
+func zoneName(t time.Time) string {
+	name, _ := t.Zone()
+	if name == "" || name[0] == '+' || name[0] == '-' {
+		return "UTC" + t.Format("-07:00")
+	}
+	return name
+}
@@ -0,0 +1,56 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/bwmarrin/discordgo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type loggerKey struct{}
+
+// withLogger returns a copy of ctx carrying log, so everything handling an event logs with its IDs
+func withLogger(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// loggerFrom returns the logger carried by ctx, the default logger if there is none
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if log, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return log
+	}
+	return slog.Default()
+}
+
+// messageContext starts handling a message event, returning the handlers' context with a
+// span and a logger describing it. The span must be ended once the event is handled.
+func (h *handlers) messageContext(event, guildID, channelID, userID, messageID string) (context.Context, trace.Span) {
+	return h.eventContext(event,
+		slog.String("guild_id", guildID),
+		slog.String("channel_id", channelID),
+		slog.String("user_id", userID),
+		slog.String("message_id", messageID),
+	)
+}
+
+// interactionContext starts handling an interaction, returning the handlers' context with a
+// span and a logger describing it. The span must be ended once the interaction is handled.
+func (h *handlers) interactionContext(i *discordgo.InteractionCreate) (context.Context, trace.Span) {
+	return h.eventContext("InteractionCreate",
+		slog.String("guild_id", i.GuildID),
+		slog.String("channel_id", i.ChannelID),
+		slog.String("user_id", interactionUserID(i)),
+		slog.String("interaction_id", i.ID),
+	)
+}
+
+// logLookupError logs a failed database lookup whose result falls back to a default,
+// missing rows are expected and not logged
+func logLookupError(ctx context.Context, err error, msg string) {
+	if err != nil && !errors.Is(err, database.ErrNoRows) {
+		loggerFrom(ctx).Warn(msg, slog.Any("error", err))
+	}
+}
@@ -0,0 +1,32 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/SHA65536/TimezoneBot/database"
+)
+
+func TestLoggerFrom(t *testing.T) {
+	if loggerFrom(context.Background()) != slog.Default() {
+		t.Error("loggerFrom() without a logger should return the default logger")
+	}
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil)).With(slog.String("guild_id", "1"))
+	ctx := withLogger(context.Background(), log)
+
+	logLookupError(ctx, database.ErrNoRows, "lookup failed")
+	if buf.Len() != 0 {
+		t.Errorf("logLookupError() logged a missing row: %q", buf.String())
+	}
+
+	logLookupError(ctx, errors.New("connection refused"), "lookup failed")
+	if got := buf.String(); !strings.Contains(got, "guild_id=1") || !strings.Contains(got, "connection refused") {
+		t.Errorf("logLookupError() = %q, want the event's IDs and the error", got)
+	}
+}
@@ -0,0 +1,62 @@
+package discord
+
+import (
+	"github.com/SHA65536/TimezoneBot/metrics"
+	"github.com/bwmarrin/discordgo"
+)
+
+// countingSession is a Session counting the Discord API calls that fail
+type countingSession struct {
+	Session
+	metrics *metrics.Metrics
+}
+
+// count records err as a failure of call, if it is one, and returns it
+func (s countingSession) count(call string, err error) error {
+	if err != nil {
+		s.metrics.DiscordError(call)
+	}
+	return err
+}
+
+func (s countingSession) ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	msg, err := s.Session.ChannelMessage(channelID, messageID, options...)
+	return msg, s.count("ChannelMessage", err)
+}
+
+func (s countingSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	msg, err := s.Session.ChannelMessageSendComplex(channelID, data, options...)
+	return msg, s.count("ChannelMessageSend", err)
+}
+
+func (s countingSession) ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	msg, err := s.Session.ChannelMessageEditComplex(m, options...)
+	return msg, s.count("ChannelMessageEdit", err)
+}
+
+func (s countingSession) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	return s.count("ChannelMessageDelete", s.Session.ChannelMessageDelete(channelID, messageID, options...))
+}
+
+func (s countingSession) MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error {
+	return s.count("MessageReactionAdd", s.Session.MessageReactionAdd(channelID, messageID, emojiID, options...))
+}
+
+func (s countingSession) MessageThreadStartComplex(channelID, messageID string, data *discordgo.ThreadStart, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	ch, err := s.Session.MessageThreadStartComplex(channelID, messageID, data, options...)
+	return ch, s.count("MessageThreadStart", err)
+}
+
+func (s countingSession) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	return s.count("InteractionRespond", s.Session.InteractionRespond(interaction, resp, options...))
+}
+
+func (s countingSession) InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	msg, err := s.Session.InteractionResponseEdit(interaction, newresp, options...)
+	return msg, s.count("InteractionResponseEdit", err)
+}
+
+func (s countingSession) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	ch, err := s.Session.UserChannelCreate(recipientID, options...)
+	return ch, s.count("UserChannelCreate", err)
+}
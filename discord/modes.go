@@ -0,0 +1,37 @@
+package discord
+
+import (
+	"context"
+	"slices"
+
+	"github.com/SHA65536/TimezoneBot/database"
+)
+
+// Channel modes decide what the bot does when it finds a time in a message
+const (
+	// ChannelModeOff ignores messages in the channel
+	ChannelModeOff = "off"
+	// ChannelModeReact only adds a reaction that can be clicked to convert, the default
+	ChannelModeReact = "react-only"
+	// ChannelModeAutoReply replies with the conversion right away
+	ChannelModeAutoReply = "auto-reply"
+	// ChannelModeAutoThread posts the conversion in a thread started from the message
+	ChannelModeAutoThread = "auto-thread"
+)
+
+var channelModes = []string{ChannelModeOff, ChannelModeReact, ChannelModeAutoReply, ChannelModeAutoThread}
+
+// isChannelMode reports whether mode is one of the known channel modes
+func isChannelMode(mode string) bool {
+	return slices.Contains(channelModes, mode)
+}
+
+// channelMode returns the configured mode of a channel, ChannelModeReact if none is set
+func channelMode(ctx context.Context, db database.Querier, channelID string) string {
+	mode, err := db.GetChannelMode(ctx, channelID)
+	if err != nil || !isChannelMode(mode) {
+		logLookupError(ctx, err, "failed to look up channel mode")
+		return ChannelModeReact
+	}
+	return mode
+}
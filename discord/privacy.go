@@ -0,0 +1,246 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	privacyDeleteButtonID = "privacy_delete"
+	privacyCancelButtonID = "privacy_cancel"
+)
+
+// privacyCommand returns the /privacy slash command
+func privacyCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "privacy",
+		Description: "See or delete what the bot stores about you",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "export",
+				Description: "Get a file with everything the bot stores about you in your DMs",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete everything the bot stores about you",
+			},
+		},
+	}
+}
+
+// userData is everything stored about a user, as sent by /privacy export
+type userData struct {
+	UserID          string              `json:"user_id"`
+	ExportedAt      time.Time           `json:"exported_at"`
+	Timezone        string              `json:"timezone,omitempty"`
+	TimestampStyle  string              `json:"timestamp_style,omitempty"`
+	GuildTimezones  []guildTimezoneData `json:"guild_timezones"`
+	Travel          *travelData         `json:"travel,omitempty"`
+	TimezoneHistory []historyData       `json:"timezone_history"`
+}
+
+type guildTimezoneData struct {
+	GuildID  string `json:"guild_id"`
+	Timezone string `json:"timezone"`
+}
+
+type travelData struct {
+	HomeTimezone string    `json:"home_timezone"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type historyData struct {
+	Timezone  string    `json:"timezone"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// handlePrivacyCommand dispatches /privacy to its subcommands
+func (h *handlers) handlePrivacyCommand(ctx context.Context, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		return
+	}
+
+	switch data.Options[0].Name {
+	case "export":
+		h.handlePrivacyExport(ctx, i)
+	case "delete":
+		h.handlePrivacyDelete(ctx, i)
+	}
+}
+
+// handlePrivacyExport DMs the user a JSON file of everything stored about them, or attaches
+// it to the answer if they don't accept DMs
+func (h *handlers) handlePrivacyExport(ctx context.Context, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+
+	var data userData
+	err := h.db.InTx(ctx, func(q database.Querier) error {
+		var err error
+		data, err = collectUserData(ctx, q, userID, h.clock.Now())
+		return err
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("failed to collect user data", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to export your data.")
+		return
+	}
+
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		loggerFrom(ctx).Error("failed to encode user data", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to export your data.")
+		return
+	}
+	file := func() *discordgo.File {
+		return &discordgo.File{
+			Name:        "timezonebot-data.json",
+			ContentType: "application/json",
+			Reader:      bytes.NewReader(encoded),
+		}
+	}
+
+	dm, err := h.session.UserChannelCreate(userID, discordgo.WithContext(ctx))
+	if err == nil {
+		_, err = h.session.ChannelMessageSendComplex(dm.ID, &discordgo.MessageSend{
+			Content: "Here is everything TimezoneBot stores about you.",
+			Files:   []*discordgo.File{file()},
+		}, discordgo.WithContext(ctx))
+	}
+	if err == nil {
+		respondEphemeral(ctx, h.session, i, "Sent you a DM with your data.")
+		return
+	}
+
+	loggerFrom(ctx).Info("failed to DM user data, attaching it instead", slog.Any("error", err))
+	err = h.session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Couldn't DM you, here is everything TimezoneBot stores about you.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Files:   []*discordgo.File{file()},
+		},
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		loggerFrom(ctx).Warn("failed to send user data", slog.Any("error", err))
+	}
+}
+
+// handlePrivacyDelete asks the user to confirm deleting their data
+func (h *handlers) handlePrivacyDelete(ctx context.Context, i *discordgo.InteractionCreate) {
+	err := h.session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "This deletes your timezone, server timezones, settings, ongoing trip and timezone history. " +
+				"Times you mentioned will no longer be converted. This can't be undone.",
+			Flags: discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Delete my data",
+						Style:    discordgo.DangerButton,
+						CustomID: fmt.Sprintf("%s:%s", privacyDeleteButtonID, interactionUserID(i)),
+					},
+					discordgo.Button{
+						Label:    "Cancel",
+						Style:    discordgo.SecondaryButton,
+						CustomID: privacyCancelButtonID,
+					},
+				}},
+			},
+		},
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		loggerFrom(ctx).Warn("failed to ask for deletion confirmation", slog.Any("error", err))
+	}
+}
+
+// handlePrivacyDeleteButton deletes the data of the user who confirmed it
+func (h *handlers) handlePrivacyDeleteButton(ctx context.Context, i *discordgo.InteractionCreate, userID string) {
+	if userID != interactionUserID(i) {
+		respondEphemeral(ctx, h.session, i, "Only the user who asked for the deletion can confirm it.")
+		return
+	}
+
+	err := h.db.InTx(ctx, func(q database.Querier) error {
+		return database.DeleteUserData(ctx, q, userID)
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("failed to delete user data", slog.Any("error", err))
+		updateConfirmation(ctx, h.session, i, "Failed to delete your data, nothing was removed.")
+		return
+	}
+	updateConfirmation(ctx, h.session, i, "Your data was deleted.")
+}
+
+// handlePrivacyCancelButton leaves the user's data alone
+func (h *handlers) handlePrivacyCancelButton(ctx context.Context, i *discordgo.InteractionCreate) {
+	updateConfirmation(ctx, h.session, i, "Nothing was deleted.")
+}
+
+// updateConfirmation replaces the deletion confirmation with content, removing its buttons
+func updateConfirmation(ctx context.Context, s Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		loggerFrom(ctx).Warn("failed to update deletion confirmation", slog.Any("error", err))
+	}
+}
+
+// collectUserData reads every row stored about userID
+func collectUserData(ctx context.Context, q database.Querier, userID string, now time.Time) (userData, error) {
+	data := userData{
+		UserID:          userID,
+		ExportedAt:      now.UTC(),
+		GuildTimezones:  []guildTimezoneData{},
+		TimezoneHistory: []historyData{},
+	}
+
+	var err error
+	if data.Timezone, err = q.GetTimezone(ctx, userID); err != nil && !errors.Is(err, database.ErrNoRows) {
+		return data, err
+	}
+	if data.TimestampStyle, err = q.GetUserTimestampStyle(ctx, userID); err != nil && !errors.Is(err, database.ErrNoRows) {
+		return data, err
+	}
+
+	travel, err := q.GetTravel(ctx, userID)
+	switch {
+	case err == nil:
+		data.Travel = &travelData{HomeTimezone: travel.HomeTimezone, ExpiresAt: travel.ExpiresAt.UTC()}
+	case !errors.Is(err, database.ErrNoRows):
+		return data, err
+	}
+
+	guildTimezones, err := q.ListUserGuildTimezones(ctx, userID)
+	if err != nil {
+		return data, err
+	}
+	for _, row := range guildTimezones {
+		data.GuildTimezones = append(data.GuildTimezones, guildTimezoneData{GuildID: row.GuildID, Timezone: row.Timezone})
+	}
+
+	history, err := q.ListTimezoneHistory(ctx, userID)
+	if err != nil {
+		return data, err
+	}
+	for _, row := range history {
+		data.TimezoneHistory = append(data.TimezoneHistory, historyData{Timezone: row.Timezone, ChangedAt: row.ChangedAt.UTC()})
+	}
+	return data, nil
+}
@@ -0,0 +1,148 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// buttonInteraction builds a click on the button customID by userID
+func buttonInteraction(userID, customID string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:    discordgo.InteractionMessageComponent,
+		GuildID: "10",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: userID}},
+		Data:    discordgo.MessageComponentInteractionData{CustomID: customID},
+	}}
+}
+
+// saveUserData stores a row of every kind for userID
+func (b *testBot) saveUserData(t *testing.T, userID string) {
+	t.Helper()
+	ctx := context.Background()
+
+	b.setTimezone(t, userID, "Europe/London")
+	if err := b.store.SetGuildTimezone(ctx, database.SetGuildTimezoneParams{GuildID: "10", UserID: userID, Timezone: "Asia/Tokyo"}); err != nil {
+		t.Fatalf("SetGuildTimezone() unexpected error: %v", err)
+	}
+	if err := b.store.SetUserTimestampStyle(ctx, database.SetUserTimestampStyleParams{UserID: userID, TimestampStyle: "F"}); err != nil {
+		t.Fatalf("SetUserTimestampStyle() unexpected error: %v", err)
+	}
+	err := b.store.SetTravel(ctx, database.SetTravelParams{UserID: userID, HomeTimezone: "Europe/London", ExpiresAt: testNow.Add(24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("SetTravel() unexpected error: %v", err)
+	}
+}
+
+// decodeExport reads the only file sent so far
+func decodeExport(t *testing.T, files []*discordgo.File) userData {
+	t.Helper()
+	if len(files) != 1 {
+		t.Fatalf("sent %d files, want 1", len(files))
+	}
+	raw, err := io.ReadAll(files[0].Reader)
+	if err != nil {
+		t.Fatalf("reading the export: %v", err)
+	}
+	var data userData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("decoding the export: %v", err)
+	}
+	return data
+}
+
+func TestHandlers_PrivacyExport(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.saveUserData(t, "1")
+
+	bot.onInteraction(commandInteraction("1", "privacy", subcommandOption("export")))
+	if got := bot.session.lastResponse(t); got != "Sent you a DM with your data." {
+		t.Errorf("/privacy export response = %q", got)
+	}
+	if len(bot.session.sent) == 0 || bot.session.sent[len(bot.session.sent)-1].ChannelID != "dm-1" {
+		t.Fatalf("/privacy export didn't DM the user")
+	}
+
+	data := decodeExport(t, bot.session.files)
+	if data.UserID != "1" || data.Timezone != "Europe/London" || data.TimestampStyle != "F" {
+		t.Errorf("export = %+v, want user 1 in Europe/London with style F", data)
+	}
+	if len(data.GuildTimezones) != 1 || data.GuildTimezones[0] != (guildTimezoneData{GuildID: "10", Timezone: "Asia/Tokyo"}) {
+		t.Errorf("export guild timezones = %+v", data.GuildTimezones)
+	}
+	if data.Travel == nil || data.Travel.HomeTimezone != "Europe/London" {
+		t.Errorf("export travel = %+v, want the trip", data.Travel)
+	}
+	if len(data.TimezoneHistory) == 0 {
+		t.Errorf("export has no timezone history")
+	}
+}
+
+func TestHandlers_PrivacyExportWithoutDMs(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+	bot.session.dmClosed = true
+
+	bot.onInteraction(commandInteraction("1", "privacy", subcommandOption("export")))
+	resp := bot.session.responses[len(bot.session.responses)-1]
+	if resp.Data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("/privacy export fallback isn't ephemeral")
+	}
+	if data := decodeExport(t, resp.Data.Files); data.Timezone != "Europe/London" {
+		t.Errorf("attached export = %+v, want the timezone", data)
+	}
+}
+
+func TestHandlers_PrivacyDelete(t *testing.T) {
+	ctx := context.Background()
+	bot := newTestBot(t, testNow)
+	bot.saveUserData(t, "1")
+	bot.setTimezone(t, "2", "Asia/Tokyo")
+
+	bot.onInteraction(commandInteraction("1", "privacy", subcommandOption("delete")))
+	if n := len(bot.session.responses[len(bot.session.responses)-1].Data.Components); n != 1 {
+		t.Fatalf("/privacy delete sent %d component rows, want the confirmation buttons", n)
+	}
+
+	// Only the user who asked can confirm
+	bot.onInteraction(buttonInteraction("2", privacyDeleteButtonID+":1"))
+	if _, err := bot.store.GetTimezone(ctx, "1"); err != nil {
+		t.Fatalf("GetTimezone() = %v after another user clicked, want the timezone kept", err)
+	}
+
+	bot.onInteraction(buttonInteraction("1", privacyDeleteButtonID+":1"))
+	resp := bot.session.responses[len(bot.session.responses)-1]
+	if resp.Type != discordgo.InteractionResponseUpdateMessage || resp.Data.Content != "Your data was deleted." {
+		t.Errorf("delete button response = %v %q", resp.Type, resp.Data.Content)
+	}
+
+	data, err := collectUserData(ctx, bot.store, "1", testNow)
+	if err != nil {
+		t.Fatalf("collectUserData() unexpected error: %v", err)
+	}
+	if data.Timezone != "" || data.TimestampStyle != "" || data.Travel != nil || len(data.GuildTimezones) != 0 || len(data.TimezoneHistory) != 0 {
+		t.Errorf("collectUserData() = %+v after deletion, want nothing", data)
+	}
+	if got, err := bot.store.GetTimezone(ctx, "2"); err != nil || got != "Asia/Tokyo" {
+		t.Errorf("GetTimezone() = %q, %v for another user, want Asia/Tokyo", got, err)
+	}
+}
+
+func TestHandlers_PrivacyCancel(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+
+	bot.onInteraction(buttonInteraction("1", privacyCancelButtonID))
+	if got := bot.session.lastResponse(t); got != "Nothing was deleted." {
+		t.Errorf("cancel button response = %q", got)
+	}
+	if _, err := bot.store.GetTimezone(context.Background(), "1"); errors.Is(err, database.ErrNoRows) {
+		t.Errorf("GetTimezone() = %v after cancelling, want the timezone kept", err)
+	}
+}
@@ -0,0 +1,62 @@
+package discord
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a per-key token bucket, used to keep busy channels from being flooded
+type rateLimiter struct {
+	mu       sync.Mutex
+	burst    float64
+	interval time.Duration
+	clock    Clock
+	buckets  map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter allows burst events per key at once, refilling one token every interval
+// as measured by clock
+func newRateLimiter(burst int, interval time.Duration, clock Clock) *rateLimiter {
+	return &rateLimiter{
+		burst:    float64(burst),
+		interval: interval,
+		clock:    clock,
+		buckets:  map[string]*bucket{},
+	}
+}
+
+// Allow reports whether an event for key may happen now, consuming a token if so
+func (rl *rateLimiter) Allow(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.clock.Now()
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = b
+	}
+
+	b.tokens = min(rl.burst, b.tokens+float64(now.Sub(b.last))/float64(rl.interval))
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+
+	// Buckets that have refilled completely carry no state, drop them so the map stays small
+	if len(rl.buckets) > 1024 {
+		for k, other := range rl.buckets {
+			if k != key && other.tokens+float64(now.Sub(other.last))/float64(rl.interval) >= rl.burst {
+				delete(rl.buckets, k)
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,63 @@
+package discord
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	clock := &fakeClock{now: testNow}
+	rl := newRateLimiter(3, time.Minute, clock)
+
+	for n := range 3 {
+		if !rl.Allow("20") {
+			t.Fatalf("Allow() = false for event %d of the burst", n+1)
+		}
+	}
+	if rl.Allow("20") {
+		t.Fatal("Allow() = true once the burst is spent")
+	}
+	// Keys have their own buckets
+	if !rl.Allow("21") {
+		t.Error("Allow() = false for another key")
+	}
+
+	clock.now = clock.now.Add(30 * time.Second)
+	if rl.Allow("20") {
+		t.Error("Allow() = true after half a refill")
+	}
+	clock.now = clock.now.Add(30 * time.Second)
+	if !rl.Allow("20") {
+		t.Error("Allow() = false after a refill")
+	}
+	if rl.Allow("20") {
+		t.Error("Allow() = true with a single token refilled")
+	}
+
+	// Refills stop at the burst
+	clock.now = clock.now.Add(time.Hour)
+	for n := range 3 {
+		if !rl.Allow("20") {
+			t.Fatalf("Allow() = false for event %d after a long pause", n+1)
+		}
+	}
+	if rl.Allow("20") {
+		t.Error("Allow() = true past the burst after a long pause")
+	}
+}
+
+func TestRateLimiter_DropsFullBuckets(t *testing.T) {
+	clock := &fakeClock{now: testNow}
+	rl := newRateLimiter(1, time.Minute, clock)
+
+	for n := range 1025 {
+		rl.Allow(fmt.Sprint(n))
+	}
+	clock.now = clock.now.Add(time.Minute)
+	rl.Allow("last")
+
+	if len(rl.buckets) != 1 {
+		t.Errorf("kept %d buckets, want only the one just used", len(rl.buckets))
+	}
+}
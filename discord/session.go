@@ -0,0 +1,32 @@
+package discord
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Session is the part of the Discord API the handlers use, so tests can stand in for Discord
+type Session interface {
+	ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error
+	MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error
+	MessageThreadStartComplex(channelID, messageID string, data *discordgo.ThreadStart, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error
+	InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+}
+
+var _ Session = (*discordgo.Session)(nil)
+
+// Clock tells the handlers what time it is
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock of the running bot
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
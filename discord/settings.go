@@ -0,0 +1,297 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	settingsScopeUser  = "me"
+	settingsScopeGuild = "server"
+
+	// resetStyle is the choice value used to clear a saved style
+	resetStyle = "default"
+
+	maxCooldownMinutes = 24 * 60
+)
+
+var minCooldownMinutes float64 = 1
+
+// settingsCommand returns the /settings slash command
+func settingsCommand() *discordgo.ApplicationCommand {
+	styleChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Default", Value: resetStyle},
+	}
+	for _, style := range timestampStyles {
+		styleChoices = append(styleChoices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  style.Name,
+			Value: style.Value,
+		})
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:        "settings",
+		Description: "Change how the bot behaves for you or this server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "timestamp-style",
+				Description: "Pick how converted times are displayed",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "style",
+						Description: "Discord timestamp style",
+						Required:    true,
+						Choices:     styleChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "scope",
+						Description: "Apply to yourself (default) or the whole server",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Me", Value: settingsScopeUser},
+							{Name: "Server", Value: settingsScopeGuild},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel-mode",
+				Description: "Pick what happens when a time is found in this channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "How to convert times in this channel",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Off", Value: ChannelModeOff},
+							{Name: "Only react with ⏰ (default)", Value: ChannelModeReact},
+							{Name: "Reply automatically", Value: ChannelModeAutoReply},
+							{Name: "Reply automatically in a thread", Value: ChannelModeAutoThread},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "cooldown",
+				Description: "Pick how long a message can't be converted again on this server",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "minutes",
+						Description: "Minutes between conversions of the same message",
+						Required:    true,
+						MinValue:    &minCooldownMinutes,
+						MaxValue:    maxCooldownMinutes,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "default-timezone",
+				Description: "Pick the timezone of members of this server who haven't set one",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "location",
+						Description:  "Server timezone (IANA), leave empty to remove it",
+						Autocomplete: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// handleSettingsCommand dispatches /settings to its subcommands
+func (h *handlers) handleSettingsCommand(ctx context.Context, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		return
+	}
+
+	sub := data.Options[0]
+	switch sub.Name {
+	case "timestamp-style":
+		h.handleTimestampStyleSetting(ctx, i, sub.Options)
+	case "channel-mode":
+		h.handleChannelModeSetting(ctx, i, sub.Options)
+	case "cooldown":
+		h.handleCooldownSetting(ctx, i, sub.Options)
+	case "default-timezone":
+		h.handleDefaultTimezoneSetting(ctx, i, sub.Options)
+	}
+}
+
+// handleTimestampStyleSetting saves the timestamp style for the user or the guild
+func (h *handlers) handleTimestampStyleSetting(ctx context.Context, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	style, scope := "", settingsScopeUser
+	for _, opt := range options {
+		switch opt.Name {
+		case "style":
+			style = opt.StringValue()
+		case "scope":
+			scope = opt.StringValue()
+		}
+	}
+
+	if style == resetStyle {
+		style = ""
+	} else if !isTimestampStyle(style) {
+		respondEphemeral(ctx, h.session, i, "Invalid timestamp style selected.")
+		return
+	}
+
+	var err error
+	switch scope {
+	case settingsScopeGuild:
+		if !canManageGuild(i) {
+			respondEphemeral(ctx, h.session, i, "You need the Manage Server permission to change server settings.")
+			return
+		}
+		err = h.db.SetGuildTimestampStyle(ctx, database.SetGuildTimestampStyleParams{
+			GuildID:        i.GuildID,
+			TimestampStyle: style,
+		})
+	default:
+		err = h.db.SetUserTimestampStyle(ctx, database.SetUserTimestampStyleParams{
+			UserID:         interactionUserID(i),
+			TimestampStyle: style,
+		})
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("failed to save timestamp style", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to save timestamp style.")
+		return
+	}
+
+	if style == "" {
+		respondEphemeral(ctx, h.session, i, "Timestamp style reset to default.")
+		return
+	}
+	respondEphemeral(ctx, h.session, i, fmt.Sprintf("Timestamp style set, converted times will look like %s", formatTimestamp(h.clock.Now().Unix(), style)))
+}
+
+// handleChannelModeSetting saves the conversion mode of the channel the command was used in
+func (h *handlers) handleChannelModeSetting(ctx context.Context, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	mode := ""
+	for _, opt := range options {
+		if opt.Name == "mode" {
+			mode = opt.StringValue()
+		}
+	}
+
+	if !isChannelMode(mode) {
+		respondEphemeral(ctx, h.session, i, "Invalid channel mode selected.")
+		return
+	}
+	if !canManageChannels(i) {
+		respondEphemeral(ctx, h.session, i, "You need the Manage Channels permission to change the channel mode.")
+		return
+	}
+
+	err := h.db.SetChannelMode(ctx, database.SetChannelModeParams{
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		Mode:      mode,
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("failed to save channel mode", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to save channel mode.")
+		return
+	}
+
+	respondEphemeral(ctx, h.session, i, fmt.Sprintf("Channel mode set to %s", mode))
+}
+
+// handleCooldownSetting saves the conversion cooldown of the guild
+func (h *handlers) handleCooldownSetting(ctx context.Context, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var minutes int64
+	for _, opt := range options {
+		if opt.Name == "minutes" {
+			minutes = opt.IntValue()
+		}
+	}
+
+	if minutes < int64(minCooldownMinutes) || minutes > maxCooldownMinutes {
+		respondEphemeral(ctx, h.session, i, "Invalid cooldown selected.")
+		return
+	}
+	if !canManageGuild(i) {
+		respondEphemeral(ctx, h.session, i, "You need the Manage Server permission to change server settings.")
+		return
+	}
+
+	err := h.db.SetGuildCooldown(ctx, database.SetGuildCooldownParams{
+		GuildID:         i.GuildID,
+		CooldownSeconds: int32(minutes * 60),
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("failed to save cooldown", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to save cooldown.")
+		return
+	}
+
+	respondEphemeral(ctx, h.session, i, fmt.Sprintf("Cooldown set to %d minutes", minutes))
+}
+
+// handleDefaultTimezoneSetting saves the timezone used for members of the guild without one
+func (h *handlers) handleDefaultTimezoneSetting(ctx context.Context, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	location := ""
+	for _, opt := range options {
+		if opt.Name == "location" {
+			location = opt.StringValue()
+		}
+	}
+
+	if !canManageGuild(i) {
+		respondEphemeral(ctx, h.session, i, "You need the Manage Server permission to change server settings.")
+		return
+	}
+
+	if location == "" {
+		if err := h.db.DeleteGuildDefaultTimezone(ctx, i.GuildID); err != nil {
+			loggerFrom(ctx).Error("failed to remove default timezone", slog.Any("error", err))
+			respondEphemeral(ctx, h.session, i, "Failed to remove default timezone.")
+			return
+		}
+		respondEphemeral(ctx, h.session, i, "Default timezone removed.")
+		return
+	}
+
+	if _, err := time.LoadLocation(location); err != nil {
+		respondEphemeral(ctx, h.session, i, "Invalid timezone selected.")
+		return
+	}
+
+	err := h.db.SetGuildDefaultTimezone(ctx, database.SetGuildDefaultTimezoneParams{
+		GuildID:  i.GuildID,
+		Timezone: location,
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("failed to save default timezone", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to save default timezone.")
+		return
+	}
+
+	respondEphemeral(ctx, h.session, i, fmt.Sprintf("Default timezone set to %s", location))
+}
+
+// canManageGuild reports whether the interaction was invoked in a guild by a member with Manage Server
+func canManageGuild(i *discordgo.InteractionCreate) bool {
+	return i.GuildID != "" && i.Member != nil && i.Member.Permissions&discordgo.PermissionManageServer != 0
+}
+
+// canManageChannels reports whether the interaction was invoked in a guild by a member with Manage Channels
+func canManageChannels(i *discordgo.InteractionCreate) bool {
+	return i.GuildID != "" && i.Member != nil && i.Member.Permissions&discordgo.PermissionManageChannels != 0
+}
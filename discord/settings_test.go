@@ -0,0 +1,253 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestHandlers_TimestampStyleSetting(t *testing.T) {
+	ctx := context.Background()
+	bot := newTestBot(t, testNow)
+
+	bot.onInteraction(commandInteraction("1", "settings", subcommandOption("timestamp-style", stringOption("style", "F"))))
+	if got := bot.session.lastResponse(t); !strings.HasPrefix(got, "Timestamp style set") {
+		t.Errorf("/settings timestamp-style response = %q", got)
+	}
+	if got := resolveTimestampStyle(ctx, bot.store, "1", "10"); got != "F" {
+		t.Errorf("resolveTimestampStyle() = %q after setting it, want F", got)
+	}
+
+	bot.onInteraction(commandInteraction("1", "settings", subcommandOption("timestamp-style", stringOption("style", "x"))))
+	if got := bot.session.lastResponse(t); got != "Invalid timestamp style selected." {
+		t.Errorf("/settings timestamp-style response = %q for an unknown style", got)
+	}
+
+	bot.onInteraction(commandInteraction("1", "settings", subcommandOption("timestamp-style", stringOption("style", resetStyle))))
+	if got := bot.session.lastResponse(t); got != "Timestamp style reset to default." {
+		t.Errorf("/settings timestamp-style response = %q for a reset", got)
+	}
+	if got := resolveTimestampStyle(ctx, bot.store, "1", "10"); got != defaultTimestampStyle {
+		t.Errorf("resolveTimestampStyle() = %q after a reset, want the default", got)
+	}
+}
+
+func TestHandlers_GuildTimestampStyleSetting(t *testing.T) {
+	ctx := context.Background()
+	bot := newTestBot(t, testNow)
+	setGuildStyle := func(permissions int64) {
+		bot.onInteraction(withPermissions(commandInteraction("1", "settings", subcommandOption("timestamp-style",
+			stringOption("style", "R"), stringOption("scope", settingsScopeGuild))), permissions))
+	}
+
+	setGuildStyle(0)
+	if got := bot.session.lastResponse(t); !strings.HasPrefix(got, "You need the Manage Server permission") {
+		t.Errorf("/settings timestamp-style response = %q without Manage Server", got)
+	}
+	if got := resolveTimestampStyle(ctx, bot.store, "2", "10"); got != defaultTimestampStyle {
+		t.Errorf("resolveTimestampStyle() = %q after a denied change, want the default", got)
+	}
+
+	setGuildStyle(discordgo.PermissionManageServer)
+	if got := bot.session.lastResponse(t); !strings.HasPrefix(got, "Timestamp style set") {
+		t.Errorf("/settings timestamp-style response = %q with Manage Server", got)
+	}
+
+	// The guild style applies to members without their own, in that guild only
+	bot.onInteraction(commandInteraction("1", "settings", subcommandOption("timestamp-style", stringOption("style", "F"))))
+	tests := []struct {
+		userID, guildID, want string
+	}{
+		{userID: "1", guildID: "10", want: "F"},
+		{userID: "2", guildID: "10", want: "R"},
+		{userID: "2", guildID: "11", want: defaultTimestampStyle},
+		{userID: "2", want: defaultTimestampStyle},
+	}
+	for _, tt := range tests {
+		if got := resolveTimestampStyle(ctx, bot.store, tt.userID, tt.guildID); got != tt.want {
+			t.Errorf("resolveTimestampStyle(%q, %q) = %q, want %q", tt.userID, tt.guildID, got, tt.want)
+		}
+	}
+}
+
+func TestHandlers_ChannelModeSetting(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+	setMode := func(mode string, permissions int64) {
+		bot.onInteraction(withPermissions(commandInteraction("1", "settings", subcommandOption("channel-mode", stringOption("mode", mode))), permissions))
+	}
+
+	setMode(ChannelModeOff, discordgo.PermissionManageServer)
+	if got := bot.session.lastResponse(t); !strings.HasPrefix(got, "You need the Manage Channels permission") {
+		t.Errorf("/settings channel-mode response = %q without Manage Channels", got)
+	}
+	if got := channelMode(context.Background(), bot.store, "20"); got != ChannelModeReact {
+		t.Errorf("channelMode() = %q after a denied change, want the default", got)
+	}
+
+	setMode("shout", discordgo.PermissionManageChannels)
+	if got := bot.session.lastResponse(t); got != "Invalid channel mode selected." {
+		t.Errorf("/settings channel-mode response = %q for an unknown mode", got)
+	}
+
+	setMode(ChannelModeOff, discordgo.PermissionManageChannels)
+	if got := bot.session.lastResponse(t); got != "Channel mode set to "+ChannelModeOff {
+		t.Errorf("/settings channel-mode response = %q with Manage Channels", got)
+	}
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: userMessage("2", "1", "see you at 18:00", testNow)})
+	if len(bot.session.reactions) != 0 || len(bot.session.sent) != 0 {
+		t.Errorf("reacted %d times and sent %d messages in an off channel", len(bot.session.reactions), len(bot.session.sent))
+	}
+
+	setMode(ChannelModeAutoReply, discordgo.PermissionManageChannels)
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: userMessage("3", "1", "see you at 18:00", testNow)})
+	if len(bot.session.sent) != 1 {
+		t.Errorf("sent %d messages in an auto-reply channel, want the conversion", len(bot.session.sent))
+	}
+}
+
+func TestHandlers_AutoReplyRateLimited(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+	bot.onInteraction(withPermissions(commandInteraction("1", "settings", subcommandOption("channel-mode",
+		stringOption("mode", ChannelModeAutoReply))), discordgo.PermissionManageChannels))
+
+	// A busy channel falls back to reactions once its automatic conversions run out
+	for n := range autoConvertBurst + 1 {
+		bot.onMessageCreate(&discordgo.MessageCreate{Message: userMessage(fmt.Sprint(n+2), "1", "at 18:00", testNow)})
+	}
+	if len(bot.session.sent) != autoConvertBurst || len(bot.session.reactions) != 1 {
+		t.Errorf("sent %d conversions and %d reactions, want %d and 1", len(bot.session.sent), len(bot.session.reactions), autoConvertBurst)
+	}
+
+	bot.clock.now = bot.clock.now.Add(autoConvertInterval)
+	bot.onMessageCreate(&discordgo.MessageCreate{Message: userMessage("100", "1", "at 18:00", testNow)})
+	if len(bot.session.sent) != autoConvertBurst+1 {
+		t.Errorf("sent %d conversions after a refill, want %d", len(bot.session.sent), autoConvertBurst+1)
+	}
+}
+
+// intOption builds an integer option of a slash command, decoded from JSON as a float
+func intOption(name string, value int64) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Type:  discordgo.ApplicationCommandOptionInteger,
+		Value: float64(value),
+	}
+}
+
+func TestHandlers_CooldownSetting(t *testing.T) {
+	ctx := context.Background()
+	bot := newTestBot(t, testNow)
+	setCooldown := func(minutes, permissions int64) {
+		bot.onInteraction(withPermissions(commandInteraction("1", "settings", subcommandOption("cooldown", intOption("minutes", minutes))), permissions))
+	}
+
+	setCooldown(30, 0)
+	if got := bot.session.lastResponse(t); !strings.HasPrefix(got, "You need the Manage Server permission") {
+		t.Errorf("/settings cooldown response = %q without Manage Server", got)
+	}
+	if got := guildCooldown(ctx, bot.store, "10"); got != defaultCooldown {
+		t.Errorf("guildCooldown() = %v after a denied change, want the default", got)
+	}
+
+	setCooldown(maxCooldownMinutes+1, discordgo.PermissionManageServer)
+	if got := bot.session.lastResponse(t); got != "Invalid cooldown selected." {
+		t.Errorf("/settings cooldown response = %q past the maximum", got)
+	}
+
+	setCooldown(30, discordgo.PermissionManageServer)
+	if got := bot.session.lastResponse(t); got != "Cooldown set to 30 minutes" {
+		t.Errorf("/settings cooldown response = %q with Manage Server", got)
+	}
+	if got := guildCooldown(ctx, bot.store, "10"); got != 30*time.Minute {
+		t.Errorf("guildCooldown() = %v, want 30m", got)
+	}
+	if got := guildCooldown(ctx, bot.store, "11"); got != defaultCooldown {
+		t.Errorf("guildCooldown() = %v for another guild, want the default", got)
+	}
+}
+
+func TestHandlers_DefaultTimezoneSetting(t *testing.T) {
+	ctx := context.Background()
+	bot := newTestBot(t, testNow)
+	setDefault := func(location string, permissions int64) {
+		var options []*discordgo.ApplicationCommandInteractionDataOption
+		if location != "" {
+			options = append(options, stringOption("location", location))
+		}
+		bot.onInteraction(withPermissions(commandInteraction("1", "settings", subcommandOption("default-timezone", options...)), permissions))
+	}
+
+	setDefault("Europe/Paris", 0)
+	if got := bot.session.lastResponse(t); !strings.HasPrefix(got, "You need the Manage Server permission") {
+		t.Errorf("/settings default-timezone response = %q without Manage Server", got)
+	}
+
+	setDefault("Mars/Olympus", discordgo.PermissionManageServer)
+	if got := bot.session.lastResponse(t); got != "Invalid timezone selected." {
+		t.Errorf("/settings default-timezone response = %q for an unknown zone", got)
+	}
+
+	setDefault("Europe/Paris", discordgo.PermissionManageServer)
+	if got := bot.session.lastResponse(t); got != "Default timezone set to Europe/Paris" {
+		t.Errorf("/settings default-timezone response = %q with Manage Server", got)
+	}
+	if got, err := resolveTimezone(ctx, bot.store, "10", "2"); err != nil || got != "Europe/Paris" {
+		t.Errorf("resolveTimezone() = %q, %v for a member without a timezone, want Europe/Paris", got, err)
+	}
+
+	setDefault("", discordgo.PermissionManageServer)
+	if got := bot.session.lastResponse(t); got != "Default timezone removed." {
+		t.Errorf("/settings default-timezone response = %q for a removal", got)
+	}
+	if _, err := resolveTimezone(ctx, bot.store, "10", "2"); err == nil {
+		t.Error("resolveTimezone() = nil error after the default was removed")
+	}
+}
+
+func TestHandlers_ServerTimezone(t *testing.T) {
+	ctx := context.Background()
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+
+	bot.onInteraction(commandInteraction("1", "timezone", stringOption("location", "Asia/Tokyo"), stringOption("scope", settingsScopeGuild)))
+	if got := bot.session.lastResponse(t); got != "Timezone on this server set to Asia/Tokyo" {
+		t.Errorf("/timezone response = %q for the server scope", got)
+	}
+	if got, _ := resolveTimezone(ctx, bot.store, "10", "1"); got != "Asia/Tokyo" {
+		t.Errorf("resolveTimezone() = %q on the server, want Asia/Tokyo", got)
+	}
+	if got, _ := resolveTimezone(ctx, bot.store, "11", "1"); got != "Europe/London" {
+		t.Errorf("resolveTimezone() = %q on another server, want Europe/London", got)
+	}
+
+	// Conversions on the server use it
+	bot.session.post(userMessage("2", "1", "at 18:00", testNow))
+	bot.onReactionAdd(&discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		UserID: "3", MessageID: "2", ChannelID: "20", GuildID: "10", Emoji: discordgo.Emoji{Name: "⏰"},
+	}})
+	// It is already March 3rd in Tokyo
+	want := time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC).Unix()
+	if len(bot.session.sent) != 1 || !strings.Contains(bot.session.sent[0].Content, fmt.Sprint(want)) {
+		t.Errorf("sent %d messages, want 18:00 in Tokyo converted", len(bot.session.sent))
+	}
+
+	// Leaving the location empty removes it
+	bot.onInteraction(commandInteraction("1", "timezone", stringOption("scope", settingsScopeGuild)))
+	if got, _ := resolveTimezone(ctx, bot.store, "10", "1"); got != "Europe/London" {
+		t.Errorf("resolveTimezone() = %q once the server timezone is removed, want Europe/London", got)
+	}
+
+	// Server timezones need a server
+	i := commandInteraction("1", "timezone", stringOption("location", "Asia/Tokyo"), stringOption("scope", settingsScopeGuild))
+	i.GuildID = ""
+	bot.onInteraction(i)
+	if got := bot.session.lastResponse(t); got != "Server timezones can only be set on a server." {
+		t.Errorf("/timezone response = %q in DMs", got)
+	}
+}
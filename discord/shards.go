@@ -0,0 +1,187 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// identifyInterval is how long Discord wants between two rounds of shards identifying
+const identifyInterval = 5 * time.Second
+
+// Sharding picks the gateway shards a DiscordServer connects. Discord requires a shard per
+// 2,500 guilds, each receiving the events of the guilds whose ID maps to it.
+type Sharding struct {
+	// Count is the total number of shards of the bot, 0 uses the count recommended by Discord
+	Count int
+	// IDs are the shards connected by this process, all of them when empty
+	IDs []int
+}
+
+// validate reports an error for shard IDs that can't be connected
+func (sh Sharding) validate() error {
+	if sh.Count < 0 {
+		return errors.New("shard count can't be negative")
+	}
+	if len(sh.IDs) > 0 && sh.Count == 0 {
+		// The recommended count changes as the bot grows, so a fixed subset of it means nothing
+		return errors.New("shard IDs require a shard count")
+	}
+	for _, id := range sh.IDs {
+		if id < 0 || id >= sh.Count {
+			return fmt.Errorf("shard ID %d is out of range for %d shards", id, sh.Count)
+		}
+	}
+	return nil
+}
+
+// ids returns the shards to connect out of count
+func (sh Sharding) ids(count int) []int {
+	if len(sh.IDs) > 0 {
+		return sh.IDs
+	}
+	ids := make([]int, count)
+	for i := range ids {
+		ids[i] = i
+	}
+	return ids
+}
+
+// ParseShardIDs parses a comma separated list of shard IDs and ranges, such as "0-3,8".
+// An empty list stands for every shard.
+func ParseShardIDs(s string) ([]int, error) {
+	var ids []int
+	if strings.TrimSpace(s) == "" {
+		return ids, nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shard ID %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil || to < from {
+				return nil, fmt.Errorf("invalid shard range %q", part)
+			}
+		}
+		for id := from; id <= to; id++ {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// newGatewaySession creates a session receiving the events the bot handles
+func newGatewaySession(token string) (*discordgo.Session, error) {
+	dg, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Discord session: %w", err)
+	}
+
+	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsGuildMessageReactions
+	dg.Client.Transport = tracingTransport{base: http.DefaultTransport}
+	return dg, nil
+}
+
+// shardPlan returns the total number of shards and how many of them may identify at once,
+// asking Discord through /gateway/bot unless a single shard is connected out of a known count
+func (s *DiscordServer) shardPlan() (count, concurrency int, err error) {
+	if s.sharding.Count == 1 {
+		return 1, 1, nil
+	}
+
+	gateway, err := s.session.GatewayBot()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error fetching recommended shard count: %w", err)
+	}
+
+	count = s.sharding.Count
+	if count == 0 {
+		count = max(gateway.Shards, 1)
+	}
+	return count, max(gateway.SessionStartLimit.MaxConcurrency, 1), nil
+}
+
+// openShards connects a session per shard, concurrency of them every identifyInterval as Discord
+// allows. The first shard uses the server's session, the others get their own with the handlers.
+func (s *DiscordServer) openShards(ctx context.Context, count, concurrency int) error {
+	ids := s.sharding.ids(count)
+	shards := make([]*discordgo.Session, 0, len(ids))
+	for n, id := range ids {
+		dg := s.session
+		if n > 0 {
+			var err error
+			if dg, err = newGatewaySession(s.token); err != nil {
+				closeShards(shards)
+				return err
+			}
+			s.handlers.addTo(dg)
+		}
+		dg.ShardID = id
+		dg.ShardCount = count
+		watchShard(dg, s.log.With(slog.Int("shard", id)))
+
+		if n > 0 && n%concurrency == 0 {
+			select {
+			case <-ctx.Done():
+				closeShards(shards)
+				return ctx.Err()
+			case <-time.After(identifyInterval):
+			}
+		}
+		if err := dg.Open(); err != nil {
+			closeShards(shards)
+			return fmt.Errorf("error opening Discord session for shard %d: %w", id, err)
+		}
+
+		shards = append(shards, dg)
+		s.mu.Lock()
+		s.shards = shards
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// closeShards closes the gateway connection of every shard, returning the first error
+func closeShards(shards []*discordgo.Session) error {
+	var err error
+	for _, dg := range shards {
+		if closeErr := dg.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// watchShard logs the connection changes of a shard's gateway session
+func watchShard(dg *discordgo.Session, log *slog.Logger) {
+	dg.AddHandler(func(_ *discordgo.Session, r *discordgo.Ready) {
+		log.Info("shard is ready", slog.Int("guilds", len(r.Guilds)))
+	})
+	dg.AddHandler(func(_ *discordgo.Session, _ *discordgo.Resumed) {
+		log.Info("shard resumed")
+	})
+	dg.AddHandler(func(_ *discordgo.Session, _ *discordgo.Disconnect) {
+		log.Warn("shard disconnected")
+	})
+}
+
+// shardName tells shards apart in health check failures
+func shardName(dg *discordgo.Session) string {
+	return fmt.Sprintf("shard %d/%d", dg.ShardID, max(dg.ShardCount, 1))
+}
@@ -0,0 +1,84 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+)
+
+// defaultTimestampStyle is used when neither the user nor the guild picked a style
+const defaultTimestampStyle = "t"
+
+// timestampStyle describes a style that can be picked with /settings
+type timestampStyle struct {
+	Name  string
+	Value string
+}
+
+// timestampStyles lists Discord's timestamp styles and a few useful combinations.
+// Every letter in a value is rendered as a <t:unix:letter> tag, anything else is kept as is.
+var timestampStyles = []timestampStyle{
+	{"Short time (16:20)", "t"},
+	{"Long time (16:20:30)", "T"},
+	{"Short date (20/04/2021)", "d"},
+	{"Long date (20 April 2021)", "D"},
+	{"Short date/time (20 April 2021 16:20)", "f"},
+	{"Long date/time (Tuesday, 20 April 2021 16:20)", "F"},
+	{"Relative (in 2 hours)", "R"},
+	{"Short time and relative", "t (R)"},
+	{"Short date/time and relative", "f (R)"},
+	{"Long date/time and relative", "F (R)"},
+}
+
+// isTimestampStyle reports whether style is one of the known timestamp styles
+func isTimestampStyle(style string) bool {
+	return slices.ContainsFunc(timestampStyles, func(ts timestampStyle) bool {
+		return ts.Value == style
+	})
+}
+
+// formatTimestamp renders a unix timestamp as Discord timestamp tags in the given style
+func formatTimestamp(unixTimestamp int64, style string) string {
+	var sb strings.Builder
+	for _, r := range style {
+		if strings.ContainsRune("tTdDfFR", r) {
+			fmt.Fprintf(&sb, "<t:%d:%c>", unixTimestamp, r)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// withDate upgrades time-only styles to their date-bearing equivalents
+func withDate(style string) string {
+	return strings.NewReplacer("t", "f", "T", "F").Replace(style)
+}
+
+// resolveTimestampStyle picks the user's style, falling back to the guild's and then the default
+func resolveTimestampStyle(ctx context.Context, db database.Querier, userID, guildID string) string {
+	style, err := db.GetUserTimestampStyle(ctx, userID)
+	if err == nil && isTimestampStyle(style) {
+		return style
+	}
+	logLookupError(ctx, err, "failed to look up user timestamp style")
+	if guildID != "" {
+		style, err := db.GetGuildTimestampStyle(ctx, guildID)
+		if err == nil && isTimestampStyle(style) {
+			return style
+		}
+		logLookupError(ctx, err, "failed to look up guild timestamp style")
+	}
+	return defaultTimestampStyle
+}
+
+// sameDay reports whether a and b fall on the same calendar day in loc
+func sameDay(a, b time.Time, loc *time.Location) bool {
+	ay, am, ad := a.In(loc).Date()
+	by, bm, bd := b.In(loc).Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,21 @@
+package discord
+
+import "testing"
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		style, want string
+	}{
+		{style: "t", want: "<t:100:t>"},
+		{style: "F (R)", want: "<t:100:F> (<t:100:R>)"},
+	}
+	for _, tt := range tests {
+		if got := formatTimestamp(100, tt.style); got != tt.want {
+			t.Errorf("formatTimestamp(100, %q) = %q, want %q", tt.style, got, tt.want)
+		}
+	}
+
+	if got := withDate("t (R)"); got != "f (R)" {
+		t.Errorf("withDate(%q) = %q, want f (R)", "t (R)", got)
+	}
+}
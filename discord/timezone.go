@@ -2,7 +2,9 @@ package discord
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 	_ "time/tzdata"
@@ -12,117 +14,194 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
-// RegisterTimezoneCommand registers the /timezone slash command and its handler
-func RegisterTimezoneCommand(s *discordgo.Session, db *database.Queries) error {
-	command := &discordgo.ApplicationCommand{
+// timezoneCommand returns the /timezone slash command
+func timezoneCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
 		Name:        "timezone",
 		Description: "Set your timezone",
 		Options: []*discordgo.ApplicationCommandOption{
 			{
 				Type:         discordgo.ApplicationCommandOptionString,
 				Name:         "location",
-				Description:  "Pick your timezone (IANA)",
-				Required:     true,
+				Description:  "Pick your timezone (IANA), leave empty with the server scope to remove it",
 				Autocomplete: true,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "scope",
+				Description: "Use it everywhere (default) or only on this server",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Everywhere", Value: settingsScopeUser},
+					{Name: "This server", Value: settingsScopeGuild},
+				},
+			},
 		},
 	}
+}
 
-	_, err := s.ApplicationCommandCreate(s.State.User.ID, "", command)
+// handleAutocomplete suggests timezones for every timezone option, including the ones of /settings and /travel
+func (h *handlers) handleAutocomplete(ctx context.Context, i *discordgo.InteractionCreate) {
+	opt := focusedOption(i.ApplicationCommandData().Options)
+	if opt == nil || (opt.Name != "location" && opt.Name != "zone") {
+		return
+	}
+
+	choices := getAutocompleteChoices(opt.StringValue())
+	err := h.session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	}, discordgo.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("cannot create slash command: %w", err)
+		loggerFrom(ctx).Warn("failed to send autocomplete choices", slog.Any("error", err))
 	}
+}
 
-	// Handle autocomplete
-	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		if i.Type != discordgo.InteractionApplicationCommandAutocomplete {
-			return
-		}
-		if i.ApplicationCommandData().Name != "timezone" {
-			return
+// handleTimezoneCommand saves the timezone picked with /timezone
+func (h *handlers) handleTimezoneCommand(ctx context.Context, i *discordgo.InteractionCreate) {
+	location, scope := "", settingsScopeUser
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "location":
+			location = opt.StringValue()
+		case "scope":
+			scope = opt.StringValue()
 		}
+	}
 
-		var userInput string
-		for _, opt := range i.ApplicationCommandData().Options {
-			if opt.Name == "location" {
-				userInput = opt.StringValue()
-				break
-			}
-		}
+	if scope == settingsScopeGuild {
+		h.handleGuildTimezone(ctx, i, location)
+		return
+	}
 
-		choices := getAutocompleteChoices(userInput)
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionApplicationCommandAutocompleteResult,
-			Data: &discordgo.InteractionResponseData{
-				Choices: choices,
-			},
+	if location == "" {
+		respondEphemeral(ctx, h.session, i, "No timezone selected.")
+		return
+	}
+
+	// Validate that the timezone is valid
+	if _, err := time.LoadLocation(location); err != nil {
+		respondEphemeral(ctx, h.session, i, "Invalid timezone selected.")
+		return
+	}
+
+	// Picking a timezone ends any ongoing trip
+	err := h.db.InTx(ctx, func(q database.Querier) error {
+		if err := q.DeleteTravel(ctx, interactionUserID(i)); err != nil {
+			return err
+		}
+		return q.SetTimezone(ctx, database.SetTimezoneParams{
+			UserID:   interactionUserID(i),
+			Timezone: location,
 		})
 	})
+	if err != nil {
+		loggerFrom(ctx).Error("failed to save timezone", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to save timezone.")
+		return
+	}
 
-	// Handle command execution
-	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		if i.Type != discordgo.InteractionApplicationCommand {
-			return
-		}
-		if i.ApplicationCommandData().Name != "timezone" {
-			return
-		}
+	respondEphemeral(ctx, h.session, i, fmt.Sprintf("Timezone set to %s", location))
+}
 
-		location := ""
-		for _, opt := range i.ApplicationCommandData().Options {
-			if opt.Name == "location" {
-				location = opt.StringValue()
-				break
-			}
-		}
+// handleGuildTimezone saves the user's timezone for the guild the command was used in,
+// removing it when location is empty so the global one applies again
+func (h *handlers) handleGuildTimezone(ctx context.Context, i *discordgo.InteractionCreate, location string) {
+	if i.GuildID == "" {
+		respondEphemeral(ctx, h.session, i, "Server timezones can only be set on a server.")
+		return
+	}
 
-		if location == "" {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "No timezone selected.",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
+	if location == "" {
+		err := h.db.DeleteGuildTimezone(ctx, database.DeleteGuildTimezoneParams{
+			GuildID: i.GuildID,
+			UserID:  interactionUserID(i),
+		})
+		if err != nil {
+			loggerFrom(ctx).Error("failed to remove server timezone", slog.Any("error", err))
+			respondEphemeral(ctx, h.session, i, "Failed to remove timezone.")
 			return
 		}
+		respondEphemeral(ctx, h.session, i, "Server timezone removed, your global timezone applies here again.")
+		return
+	}
 
-		// Validate that the timezone is valid
-		if _, err := time.LoadLocation(location); err != nil {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "Invalid timezone selected.",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
-			return
-		}
+	if _, err := time.LoadLocation(location); err != nil {
+		respondEphemeral(ctx, h.session, i, "Invalid timezone selected.")
+		return
+	}
 
-		err := db.SetTimezone(context.Background(), database.SetTimezoneParams{
-			UserID:   i.Member.User.ID,
-			Timezone: location,
+	err := h.db.SetGuildTimezone(ctx, database.SetGuildTimezoneParams{
+		GuildID:  i.GuildID,
+		UserID:   interactionUserID(i),
+		Timezone: location,
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("failed to save timezone", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to save timezone.")
+		return
+	}
+
+	respondEphemeral(ctx, h.session, i, fmt.Sprintf("Timezone on this server set to %s", location))
+}
+
+// resolveTimezone returns the timezone of a user in a guild: their timezone for that guild,
+// then their global timezone, then the guild's default. ErrNoRows is returned if none is
+// set, other errors stop the lookup. /travel only changes the global timezone, so a
+// timezone for the guild keeps applying there during a trip.
+func resolveTimezone(ctx context.Context, db database.Querier, guildID, userID string) (string, error) {
+	return resolveTimezoneAt(ctx, db, guildID, userID, time.Time{})
+}
+
+// resolveTimezoneAt is resolveTimezone with the global timezone that was in effect at a
+// point in time, so old messages convert from the zone their author was in back then.
+// A zero time uses the current global timezone. Timezones for a guild keep no history, the
+// current one applies to messages of any time.
+func resolveTimezoneAt(ctx context.Context, db database.Querier, guildID, userID string, at time.Time) (string, error) {
+	if guildID != "" {
+		timezone, err := db.GetGuildTimezone(ctx, database.GetGuildTimezoneParams{
+			GuildID: guildID,
+			UserID:  userID,
 		})
-		if err != nil {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "Failed to save timezone.",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
-			return
+		if !errors.Is(err, database.ErrNoRows) {
+			return timezone, err
 		}
+	}
 
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("Timezone set to %s", location),
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
+	timezone, err := globalTimezoneAt(ctx, db, userID, at)
+	if !errors.Is(err, database.ErrNoRows) || guildID == "" {
+		return timezone, err
+	}
+
+	return db.GetGuildDefaultTimezone(ctx, guildID)
+}
+
+// globalTimezoneAt returns the user's global timezone at a point in time, falling back to the
+// current one for zero times and times before it was first set
+func globalTimezoneAt(ctx context.Context, db database.Querier, userID string, at time.Time) (string, error) {
+	if !at.IsZero() {
+		timezone, err := db.GetTimezoneAt(ctx, database.GetTimezoneAtParams{
+			UserID:    userID,
+			ChangedAt: at,
 		})
-	})
+		if !errors.Is(err, database.ErrNoRows) {
+			return timezone, err
+		}
+	}
+	return db.GetTimezone(ctx, userID)
+}
 
+// focusedOption returns the option being autocompleted, looking inside subcommands
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range options {
+		if opt.Focused {
+			return opt
+		}
+		if found := focusedOption(opt.Options); found != nil {
+			return found
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,97 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+)
+
+// timezoneQuerier serves timezone lookups from fixed data, with a single history change
+type timezoneQuerier struct {
+	database.Querier
+	global        map[string]string
+	history       map[string]string // user ID to the timezone before historyChange
+	historyChange time.Time
+	guild         map[string]string // guild ID + ":" + user ID to timezone
+	guildDefaults map[string]string
+	failing       string // name of a lookup that fails with errLookup
+}
+
+var errLookup = errors.New("connection refused")
+
+func (q *timezoneQuerier) GetTimezone(_ context.Context, userID string) (string, error) {
+	return q.lookup("GetTimezone", q.global, userID)
+}
+
+func (q *timezoneQuerier) GetTimezoneAt(_ context.Context, arg database.GetTimezoneAtParams) (string, error) {
+	if arg.ChangedAt.Before(q.historyChange) {
+		return q.lookup("GetTimezoneAt", q.history, arg.UserID)
+	}
+	return q.lookup("GetTimezoneAt", q.global, arg.UserID)
+}
+
+func (q *timezoneQuerier) GetGuildTimezone(_ context.Context, arg database.GetGuildTimezoneParams) (string, error) {
+	return q.lookup("GetGuildTimezone", q.guild, arg.GuildID+":"+arg.UserID)
+}
+
+func (q *timezoneQuerier) GetGuildDefaultTimezone(_ context.Context, guildID string) (string, error) {
+	return q.lookup("GetGuildDefaultTimezone", q.guildDefaults, guildID)
+}
+
+func (q *timezoneQuerier) lookup(method string, m map[string]string, key string) (string, error) {
+	if method == q.failing {
+		return "", errLookup
+	}
+	if value, ok := m[key]; ok {
+		return value, nil
+	}
+	return "", database.ErrNoRows
+}
+
+func TestResolveTimezoneAt(t *testing.T) {
+	q := &timezoneQuerier{
+		global:        map[string]string{"1": "Asia/Tokyo", "2": "Asia/Tokyo"},
+		history:       map[string]string{"1": "Europe/London"},
+		historyChange: testNow,
+		guild:         map[string]string{"10:2": "America/New_York"},
+		guildDefaults: map[string]string{"10": "Europe/Paris"},
+	}
+	before := testNow.Add(-time.Hour)
+
+	tests := []struct {
+		name            string
+		guildID, userID string
+		at              time.Time
+		failing         string
+		want            string
+		wantErr         error
+	}{
+		{name: "current global", guildID: "10", userID: "1", want: "Asia/Tokyo"},
+		{name: "global from history", guildID: "10", userID: "1", at: before, want: "Europe/London"},
+		{name: "history in DMs", userID: "1", at: before, want: "Europe/London"},
+		{name: "guild override beats global", guildID: "10", userID: "2", want: "America/New_York"},
+		// Overrides keep no history, old messages use the current one
+		{name: "guild override beats history", guildID: "10", userID: "2", at: before, want: "America/New_York"},
+		{name: "override only in its guild", guildID: "11", userID: "2", want: "Asia/Tokyo"},
+		{name: "guild default", guildID: "10", userID: "3", want: "Europe/Paris"},
+		{name: "nothing set", guildID: "11", userID: "3", wantErr: database.ErrNoRows},
+		// A failed lookup must not fall through to a zone the user didn't pick for this message
+		{name: "guild override fails", guildID: "10", userID: "1", failing: "GetGuildTimezone", wantErr: errLookup},
+		{name: "history fails", guildID: "10", userID: "1", at: before, failing: "GetTimezoneAt", wantErr: errLookup},
+		{name: "global fails", guildID: "10", userID: "3", failing: "GetTimezone", wantErr: errLookup},
+		{name: "guild default fails", guildID: "10", userID: "3", failing: "GetGuildDefaultTimezone", wantErr: errLookup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q.failing = tt.failing
+			got, err := resolveTimezoneAt(context.Background(), q, tt.guildID, tt.userID, tt.at)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("resolveTimezoneAt() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
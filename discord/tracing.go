@@ -0,0 +1,111 @@
+package discord
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/SHA65536/TimezoneBot/parser"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/SHA65536/TimezoneBot/discord"
+
+// eventContext starts a span named event, returning the handlers' context carrying it along
+// with a logger. attrs describe the event on both, and the logger also gets the trace ID.
+func (h *handlers) eventContext(event string, attrs ...slog.Attr) (context.Context, trace.Span) {
+	spanAttrs := make([]attribute.KeyValue, 0, len(attrs))
+	logAttrs := make([]any, 0, len(attrs)+1)
+	for _, attr := range attrs {
+		spanAttrs = append(spanAttrs, attribute.String("discord."+attr.Key, attr.Value.String()))
+		logAttrs = append(logAttrs, attr)
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(h.ctx, event,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(spanAttrs...),
+	)
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		logAttrs = append(logAttrs, slog.String("trace_id", sc.TraceID().String()))
+	}
+	return withLogger(ctx, h.log.With(logAttrs...)), span
+}
+
+// parseTime finds the time mentioned in content, tracing how long it took
+func (h *handlers) parseTime(ctx context.Context, content string) (uint, parser.TimeFormat, error) {
+	_, span := otel.Tracer(tracerName).Start(ctx, "ParseTime")
+	defer span.End()
+
+	seconds, format, err := h.parser.ParseTimeWithFormat(content)
+	if err == nil {
+		span.SetAttributes(attribute.String("timezonebot.time_format", format.Name))
+	}
+	return seconds, format, err
+}
+
+// tracingTransport starts a client span for every Discord REST request, as a child of
+// the span in the request's context
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+func (t tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), req.Method+" "+restRoute(req.URL.Path),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			semconv.URLPath(req.URL.Path),
+		),
+	)
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// restRoute replaces the IDs and tokens in a Discord API path with placeholders,
+// so requests to the same endpoint share a span name
+func restRoute(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case i > 0 && segments[i-1] == "reactions":
+			// Emoji names are as varied as IDs
+			segments[i] = "{emoji}"
+		case i > 1 && segments[i-2] == "interactions":
+			segments[i] = "{token}"
+		case isSnowflake(segment):
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isSnowflake reports whether s looks like a Discord ID
+func isSnowflake(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package discord
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans sends the spans started during the test to an in-memory exporter
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func TestHandlers_Tracing(t *testing.T) {
+	bot := newTestBot(t, testNow)
+	bot.setTimezone(t, "1", "Europe/London")
+	bot.session.post(userMessage("2", "1", "see you at 18:00", testNow))
+
+	exporter := recordSpans(t)
+	bot.onReactionAdd(&discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		UserID: "3", MessageID: "2", ChannelID: "20", GuildID: "10", Emoji: discordgo.Emoji{Name: "⏰"},
+	}})
+
+	spans := exporter.GetSpans()
+	var root tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == "MessageReactionAdd" {
+			root = span
+		}
+	}
+	if !root.SpanContext.IsValid() {
+		t.Fatalf("spans = %v, want a MessageReactionAdd span", spans)
+	}
+
+	children := map[string]bool{}
+	for _, span := range spans {
+		if span.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("span %s is in another trace", span.Name)
+		}
+		if span.Parent.SpanID() == root.SpanContext.SpanID() {
+			children[span.Name] = true
+		}
+	}
+	for _, want := range []string{"ParseTime", "GetTimezoneAt", "SetConvertReply"} {
+		if !children[want] {
+			t.Errorf("MessageReactionAdd has no %s child span, children are %v", want, children)
+		}
+	}
+}
+
+func TestRestRoute(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/v9/channels/123/messages/456", "/api/v9/channels/{id}/messages/{id}"},
+		{"/api/v9/channels/123/messages/456/reactions/%E2%8F%B0/@me", "/api/v9/channels/{id}/messages/{id}/reactions/{emoji}/@me"},
+		{"/api/v9/interactions/123/aW50ZXJhY3Rpb24/callback", "/api/v9/interactions/{id}/{token}/callback"},
+	}
+	for _, tt := range tests {
+		if got := restRoute(tt.path); got != tt.want {
+			t.Errorf("restRoute(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTracingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	exporter := recordSpans(t)
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v9/channels/123/messages/456", nil)
+	client := &http.Client{Transport: tracingTransport{base: http.DefaultTransport}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want the request and its parent", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /api/v9/channels/{id}/messages/{id}" || span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span %q with parent %v, want the route as a child of the caller's span", span.Name, span.Parent.SpanID())
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("span status = %v for a 404, want an error", span.Status)
+	}
+}
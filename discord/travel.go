@@ -0,0 +1,136 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxTravelDuration is how far ahead a trip may end
+const maxTravelDuration = 365 * 24 * time.Hour
+
+// travelCommand returns the /travel slash command
+func travelCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "travel",
+		Description: "Use another timezone until a date, then switch back",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "zone",
+				Description:  "Timezone you are travelling to (IANA)",
+				Required:     true,
+				Autocomplete: true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "until",
+				Description: "Date you are back, as YYYY-MM-DD",
+				Required:    true,
+			},
+		},
+	}
+}
+
+// handleTravelCommand switches the user to another timezone until the picked date
+func (h *handlers) handleTravelCommand(ctx context.Context, i *discordgo.InteractionCreate) {
+	zone, until := "", ""
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "zone":
+			zone = opt.StringValue()
+		case "until":
+			until = opt.StringValue()
+		}
+	}
+
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		respondEphemeral(ctx, h.session, i, "Invalid timezone selected.")
+		return
+	}
+
+	// The trip ends when the date starts at the destination
+	expires, err := time.ParseInLocation(time.DateOnly, until, loc)
+	if err != nil {
+		respondEphemeral(ctx, h.session, i, "Invalid date, use the YYYY-MM-DD format.")
+		return
+	}
+	if !expires.After(h.clock.Now()) || expires.After(h.clock.Now().Add(maxTravelDuration)) {
+		respondEphemeral(ctx, h.session, i, "The date must be in the future and within a year.")
+		return
+	}
+
+	userID := interactionUserID(i)
+	err = h.db.InTx(ctx, func(q database.Querier) error {
+		home, err := q.GetTimezone(ctx, userID)
+		if err != nil {
+			return err
+		}
+		// An ongoing trip keeps the zone it will return to
+		err = q.SetTravel(ctx, database.SetTravelParams{
+			UserID:       userID,
+			HomeTimezone: home,
+			ExpiresAt:    expires,
+		})
+		if err != nil {
+			return err
+		}
+		return q.SetTimezone(ctx, database.SetTimezoneParams{
+			UserID:   userID,
+			Timezone: zone,
+		})
+	})
+	if errors.Is(err, database.ErrNoRows) {
+		respondEphemeral(ctx, h.session, i, "You haven't set a timezone yet, use /timezone first.")
+		return
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("failed to save travel", slog.Any("error", err))
+		respondEphemeral(ctx, h.session, i, "Failed to save travel.")
+		return
+	}
+
+	content := fmt.Sprintf("Timezone set to %s until %s", zone, formatTimestamp(expires.Unix(), "F"))
+	// A timezone for this server takes precedence over the trip, see resolveTimezone
+	if i.GuildID != "" {
+		guildTimezone, err := h.db.GetGuildTimezone(ctx, database.GetGuildTimezoneParams{GuildID: i.GuildID, UserID: userID})
+		if err == nil {
+			content += fmt.Sprintf("\nYour timezone on this server stays %s, remove it with /timezone and the server scope to use the trip here too.", guildTimezone)
+		} else {
+			logLookupError(ctx, err, "failed to look up server timezone")
+		}
+	}
+	respondEphemeral(ctx, h.session, i, content)
+}
+
+// revertTravels switches users whose trip is over back to their home timezone
+func revertTravels(ctx context.Context, db database.Store) error {
+	travels, err := db.ListExpiredTravels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list expired travels: %w", err)
+	}
+
+	for _, travel := range travels {
+		err := db.InTx(ctx, func(q database.Querier) error {
+			// The trip may have been extended or cancelled since it was listed
+			rows, err := q.DeleteExpiredTravel(ctx, travel.UserID)
+			if err != nil || rows == 0 {
+				return err
+			}
+			return q.SetTimezone(ctx, database.SetTimezoneParams{
+				UserID:   travel.UserID,
+				Timezone: travel.HomeTimezone,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to revert travel of %s: %w", travel.UserID, err)
+		}
+	}
+	return nil
+}
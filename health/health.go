@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check reports why a part of the bot is unhealthy, or nil if it is fine
+type Check func(ctx context.Context) error
+
+// Checks serves liveness and readiness probes for container orchestrators.
+// The bot is live while its live checks pass, a failing one means it is wedged and
+// should be restarted. It is ready while its live and ready checks all pass.
+type Checks struct {
+	timeout time.Duration
+
+	mu    sync.Mutex
+	live  []namedCheck
+	ready []namedCheck
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// New creates a set of checks, each of which is given timeout to complete
+func New(timeout time.Duration) *Checks {
+	return &Checks{timeout: timeout}
+}
+
+// Live adds a check the bot must pass to be considered alive
+func (c *Checks) Live(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.live = append(c.live, namedCheck{name, check})
+}
+
+// Ready adds a check the bot must pass to be able to serve, on top of the live checks
+func (c *Checks) Ready(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ready = append(c.ready, namedCheck{name, check})
+}
+
+// Register serves the liveness probe on /healthz and the readiness probe on /readyz
+func (c *Checks) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		c.serve(w, r, c.checks(false))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		c.serve(w, r, c.checks(true))
+	})
+}
+
+// checks returns the live checks, followed by the ready checks if ready is set
+func (c *Checks) checks(ready bool) []namedCheck {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	checks := append([]namedCheck(nil), c.live...)
+	if ready {
+		checks = append(checks, c.ready...)
+	}
+	return checks
+}
+
+// report is the body of a probe response
+type report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// serve runs checks concurrently and answers 200 if they all pass, 503 otherwise
+func (c *Checks) serve(w http.ResponseWriter, r *http.Request, checks []namedCheck) {
+	ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+	defer cancel()
+
+	results := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = nc.check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	rep := report{Status: "ok", Checks: make(map[string]string, len(checks))}
+	code := http.StatusOK
+	for i, nc := range checks {
+		if err := results[i]; err != nil {
+			rep.Checks[nc.name] = err.Error()
+			rep.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		} else {
+			rep.Checks[nc.name] = "ok"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(rep)
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// probe requests path and decodes the report
+func probe(t *testing.T, mux *http.ServeMux, path string) (int, report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+
+	var rep report
+	if err := json.NewDecoder(rec.Body).Decode(&rep); err != nil {
+		t.Fatalf("decoding %s: %v", path, err)
+	}
+	return rec.Code, rep
+}
+
+func TestChecks(t *testing.T) {
+	var dbErr error
+	checks := New(time.Second)
+	checks.Live("heartbeat", func(context.Context) error { return nil })
+	checks.Ready("database", func(context.Context) error { return dbErr })
+
+	mux := http.NewServeMux()
+	checks.Register(mux)
+
+	if code, rep := probe(t, mux, "/readyz"); code != http.StatusOK || rep.Checks["database"] != "ok" {
+		t.Errorf("/readyz = %d %+v, want 200 with every check ok", code, rep)
+	}
+
+	dbErr = errors.New("connection refused")
+	code, rep := probe(t, mux, "/readyz")
+	if code != http.StatusServiceUnavailable || rep.Status != "unavailable" || rep.Checks["database"] != "connection refused" {
+		t.Errorf("/readyz = %d %+v, want 503 naming the failed check", code, rep)
+	}
+
+	// A broken database doesn't make the bot wedged
+	if code, rep := probe(t, mux, "/healthz"); code != http.StatusOK || len(rep.Checks) != 1 {
+		t.Errorf("/healthz = %d %+v, want 200 with only the live checks", code, rep)
+	}
+}
+
+func TestChecks_Timeout(t *testing.T) {
+	checks := New(10 * time.Millisecond)
+	checks.Live("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	mux := http.NewServeMux()
+	checks.Register(mux)
+	if code, _ := probe(t, mux, "/healthz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/healthz = %d for a check that never finishes, want 503", code)
+	}
+}
@@ -0,0 +1,131 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineOctets is the longest content line allowed by RFC 5545 3.1, excluding the line break
+	maxLineOctets = 75
+
+	dateTimeUTC   = "20060102T150405Z"
+	dateTimeLocal = "20060102T150405"
+)
+
+// Calendar represents a VCALENDAR object
+type Calendar struct {
+	ProdID string
+	Events []Event
+}
+
+// Event represents a VEVENT component
+type Event struct {
+	UID         string
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// Encode writes the calendar to w as an RFC 5545 iCalendar stream
+func (c *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	lw := &lineWriter{w: bw}
+
+	lw.property("BEGIN", "VCALENDAR")
+	lw.property("VERSION", "2.0")
+	lw.property("PRODID", escapeText(c.ProdID))
+	lw.property("CALSCALE", "GREGORIAN")
+	for _, e := range c.Events {
+		if e.UID == "" {
+			return fmt.Errorf("event is missing a UID")
+		}
+		if e.Start.IsZero() {
+			return fmt.Errorf("event %s is missing a start time", e.UID)
+		}
+
+		lw.property("BEGIN", "VEVENT")
+		lw.property("UID", escapeText(e.UID))
+		lw.property("DTSTAMP", e.Stamp.UTC().Format(dateTimeUTC))
+		lw.dateTime("DTSTART", e.Start)
+		if !e.End.IsZero() {
+			lw.dateTime("DTEND", e.End)
+		}
+		if e.Summary != "" {
+			lw.property("SUMMARY", escapeText(e.Summary))
+		}
+		if e.Description != "" {
+			lw.property("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.URL != "" {
+			lw.property("URL", e.URL)
+		}
+		lw.property("END", "VEVENT")
+	}
+	lw.property("END", "VCALENDAR")
+
+	if lw.err != nil {
+		return lw.err
+	}
+	return bw.Flush()
+}
+
+// lineWriter writes folded content lines and remembers the first error
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// dateTime writes a DATE-TIME property in UTC form. A local time with a TZID would need a
+// matching VTIMEZONE component (RFC 5545 3.2.19), UTC needs none and means the same instant.
+func (lw *lineWriter) dateTime(name string, t time.Time) {
+	lw.property(name, t.UTC().Format(dateTimeUTC))
+}
+
+// property writes a single content line: name ":" value
+func (lw *lineWriter) property(name, value string) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = lw.w.WriteString(fold(name + ":" + value))
+}
+
+// fold splits a content line into chunks of at most 75 octets without breaking
+// UTF-8 sequences, joined by CRLF followed by a single space (RFC 5545 3.1)
+func fold(line string) string {
+	var sb strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines lose one octet to the leading space
+		limit = maxLineOctets - 1
+	}
+	sb.WriteString(line)
+	sb.WriteString("\r\n")
+	return sb.String()
+}
+
+// escapeText escapes a TEXT value (RFC 5545 3.3.11)
+func escapeText(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return r.Replace(s)
+}
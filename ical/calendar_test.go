@@ -0,0 +1,196 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_RoundTrip(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{"utc start", Event{
+			UID:     "1@example.com",
+			Stamp:   time.Date(2025, 7, 12, 8, 0, 0, 0, time.UTC),
+			Start:   time.Date(2025, 7, 12, 18, 30, 0, 0, time.UTC),
+			Summary: "Meeting",
+		}},
+		{"non-UTC start and end", Event{
+			UID:     "2@example.com",
+			Stamp:   time.Date(2025, 7, 12, 8, 0, 0, 0, time.UTC),
+			Start:   time.Date(2025, 3, 30, 3, 0, 0, 0, berlin),
+			End:     time.Date(2025, 3, 30, 4, 0, 0, 0, berlin),
+			Summary: "After the DST jump",
+		}},
+		{"escaped text", Event{
+			UID:         "3@example.com",
+			Stamp:       time.Date(2025, 7, 12, 8, 0, 0, 0, time.UTC),
+			Start:       time.Date(2025, 7, 12, 18, 30, 0, 0, time.UTC),
+			Summary:     `Lunch; then coffee, maybe \ tea`,
+			Description: "line one\nline two",
+			URL:         "https://discord.com/channels/1/2/3",
+		}},
+		{"long unicode text", Event{
+			UID:         "4@example.com",
+			Stamp:       time.Date(2025, 7, 12, 8, 0, 0, 0, time.UTC),
+			Start:       time.Date(2025, 7, 12, 18, 30, 0, 0, time.UTC),
+			Summary:     strings.Repeat("⏰ time ", 40),
+			Description: strings.Repeat("a", 300),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := &Calendar{ProdID: "-//TimezoneBot//EN", Events: []Event{tt.event}}
+
+			var buf bytes.Buffer
+			if err := in.Encode(&buf); err != nil {
+				t.Fatalf("Encode() unexpected error: %v", err)
+			}
+			encoded := buf.String()
+
+			out, err := Decode(&buf)
+			if err != nil {
+				t.Fatalf("Decode() unexpected error: %v", err)
+			}
+
+			if out.ProdID != in.ProdID {
+				t.Errorf("ProdID = %q, want %q", out.ProdID, in.ProdID)
+			}
+			if len(out.Events) != 1 {
+				t.Fatalf("got %d events, want 1", len(out.Events))
+			}
+
+			got, want := out.Events[0], tt.event
+			if got.UID != want.UID || got.Summary != want.Summary || got.Description != want.Description || got.URL != want.URL {
+				t.Errorf("Decode() = %+v, want %+v", got, want)
+			}
+			if !got.Stamp.Equal(want.Stamp) || !got.Start.Equal(want.Start) || !got.End.Equal(want.End) {
+				t.Errorf("Decode() times = %v %v %v, want %v %v %v", got.Stamp, got.Start, got.End, want.Stamp, want.Start, want.End)
+			}
+			// Times are written in UTC, so no VTIMEZONE is needed
+			if got.Start.Location() != time.UTC || got.End.Location() != time.UTC {
+				t.Errorf("Decode() locations = %s %s, want UTC", got.Start.Location(), got.End.Location())
+			}
+			if strings.Contains(encoded, "TZID") {
+				t.Errorf("Encode() wrote a TZID without a VTIMEZONE")
+			}
+		})
+	}
+}
+
+func TestCalendar_Encode(t *testing.T) {
+	cal := &Calendar{
+		ProdID: "-//TimezoneBot//EN",
+		Events: []Event{{
+			UID:     "1@example.com",
+			Stamp:   time.Date(2025, 7, 12, 8, 0, 0, 0, time.UTC),
+			Start:   time.Date(2025, 7, 12, 18, 30, 0, 0, time.UTC),
+			Summary: "Meeting, at 6",
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		t.Fatalf("Encode() unexpected error: %v", err)
+	}
+
+	expected := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//TimezoneBot//EN\r\n" +
+		"CALSCALE:GREGORIAN\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:1@example.com\r\n" +
+		"DTSTAMP:20250712T080000Z\r\n" +
+		"DTSTART:20250712T183000Z\r\n" +
+		"SUMMARY:Meeting\\, at 6\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	if buf.String() != expected {
+		t.Errorf("Encode() =\n%q\nwant\n%q", buf.String(), expected)
+	}
+}
+
+func TestCalendar_EncodeMissingFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{"missing uid", Event{Start: time.Now()}},
+		{"missing start", Event{UID: "1@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal := &Calendar{Events: []Event{tt.event}}
+			if err := cal.Encode(&bytes.Buffer{}); err == nil {
+				t.Errorf("Encode() expected error but got none")
+			}
+		})
+	}
+}
+
+func TestFold(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("é", 100)
+	folded := fold(line)
+
+	for _, l := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		if len(l) > maxLineOctets {
+			t.Errorf("folded line has %d octets, want at most %d", len(l), maxLineOctets)
+		}
+		if !strings.HasPrefix(l, "SUMMARY") && !strings.HasPrefix(l, " ") {
+			t.Errorf("continuation line %q does not start with a space", l)
+		}
+	}
+
+	if unfolded := strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""); unfolded != line {
+		t.Errorf("unfolded line = %q, want %q", unfolded, line)
+	}
+}
+
+func TestDecode_TZID(t *testing.T) {
+	// Calendars from other apps use local times with a TZID
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1@example.com\r\n" +
+		"DTSTART;TZID=Europe/Berlin:20250330T030000\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	cal, err := Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if len(cal.Events) != 1 {
+		t.Fatalf("got %d events, want 1", len(cal.Events))
+	}
+
+	want := time.Date(2025, 3, 30, 1, 0, 0, 0, time.UTC)
+	if start := cal.Events[0].Start; !start.Equal(want) || start.Location().String() != "Europe/Berlin" {
+		t.Errorf("Decode() start = %v, want %v in Europe/Berlin", start, want)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"missing end", "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"},
+		{"unterminated event", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n"},
+		{"line without value", "BEGIN:VCALENDAR\r\nVERSION\r\nEND:VCALENDAR\r\n"},
+		{"unknown tzid", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART;TZID=Nowhere/Land:20250101T000000\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("Decode() expected error but got none")
+			}
+		})
+	}
+}
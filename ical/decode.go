@@ -0,0 +1,193 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// param is a single property parameter, such as TZID=Europe/London
+type param struct {
+	Name  string
+	Value string
+}
+
+// contentLine is an unfolded content line split into its parts
+type contentLine struct {
+	Name   string
+	Params []param
+	Value  string
+}
+
+// Decode reads an iCalendar stream and returns the first VCALENDAR in it
+func Decode(r io.Reader) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var cal *Calendar
+	var event *Event
+	for _, raw := range lines {
+		line, err := parseContentLine(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case line.Name == "BEGIN" && line.Value == "VCALENDAR":
+			if cal != nil {
+				return nil, fmt.Errorf("nested VCALENDAR")
+			}
+			cal = &Calendar{}
+		case line.Name == "END" && line.Value == "VCALENDAR":
+			if cal == nil || event != nil {
+				return nil, fmt.Errorf("unexpected END:VCALENDAR")
+			}
+			return cal, nil
+		case cal == nil:
+			return nil, fmt.Errorf("content line outside of VCALENDAR: %s", line.Name)
+		case line.Name == "BEGIN" && line.Value == "VEVENT":
+			if event != nil {
+				return nil, fmt.Errorf("nested VEVENT")
+			}
+			event = &Event{}
+		case line.Name == "END" && line.Value == "VEVENT":
+			if event == nil {
+				return nil, fmt.Errorf("unexpected END:VEVENT")
+			}
+			cal.Events = append(cal.Events, *event)
+			event = nil
+		case event != nil:
+			if err := event.setProperty(line); err != nil {
+				return nil, err
+			}
+		case line.Name == "PRODID":
+			cal.ProdID = unescapeText(line.Value)
+		}
+	}
+
+	return nil, fmt.Errorf("missing END:VCALENDAR")
+}
+
+// setProperty stores a known VEVENT property, ignoring the rest
+func (e *Event) setProperty(line contentLine) error {
+	var err error
+	switch line.Name {
+	case "UID":
+		e.UID = unescapeText(line.Value)
+	case "DTSTAMP":
+		e.Stamp, err = parseDateTime(line)
+	case "DTSTART":
+		e.Start, err = parseDateTime(line)
+	case "DTEND":
+		e.End, err = parseDateTime(line)
+	case "SUMMARY":
+		e.Summary = unescapeText(line.Value)
+	case "DESCRIPTION":
+		e.Description = unescapeText(line.Value)
+	case "URL":
+		e.URL = line.Value
+	}
+	return err
+}
+
+// unfold reads all content lines, joining folded continuation lines
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += text[1:]
+			continue
+		}
+		lines = append(lines, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// parseContentLine splits a content line into name, parameters and value,
+// honouring quoted parameter values that may contain ':' or ';'
+func parseContentLine(raw string) (contentLine, error) {
+	var line contentLine
+	var fields []string
+	var current strings.Builder
+	quoted := false
+
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		switch {
+		case ch == '"':
+			quoted = !quoted
+		case ch == ';' && !quoted:
+			fields = append(fields, current.String())
+			current.Reset()
+		case ch == ':' && !quoted:
+			fields = append(fields, current.String())
+			line.Value = raw[i+1:]
+			line.Name = strings.ToUpper(fields[0])
+			for _, f := range fields[1:] {
+				name, value, ok := strings.Cut(f, "=")
+				if !ok {
+					return line, fmt.Errorf("malformed parameter %q in %s", f, line.Name)
+				}
+				line.Params = append(line.Params, param{strings.ToUpper(name), value})
+			}
+			if line.Name == "" {
+				return line, fmt.Errorf("content line without a name: %q", raw)
+			}
+			return line, nil
+		default:
+			current.WriteByte(ch)
+		}
+	}
+
+	return line, fmt.Errorf("content line without a value: %q", raw)
+}
+
+// parseDateTime parses a DATE-TIME value, either in UTC form or local form with a TZID
+func parseDateTime(line contentLine) (time.Time, error) {
+	if strings.HasSuffix(line.Value, "Z") {
+		return time.Parse(dateTimeUTC, line.Value)
+	}
+
+	loc := time.UTC
+	for _, p := range line.Params {
+		if p.Name == "TZID" {
+			l, err := time.LoadLocation(p.Value)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("unknown TZID %q: %w", p.Value, err)
+			}
+			loc = l
+		}
+	}
+	return time.ParseInLocation(dateTimeLocal, line.Value, loc)
+}
+
+// unescapeText reverses escapeText
+func unescapeText(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			sb.WriteByte('\n')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
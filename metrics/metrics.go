@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "timezonebot"
+
+// Metrics holds the bot's Prometheus collectors
+type Metrics struct {
+	registry *prometheus.Registry
+
+	messagesScanned      prometheus.Counter
+	timeMatches          *prometheus.CounterVec
+	reactionsAdded       prometheus.Counter
+	conversionsSent      prometheus.Counter
+	cooldownSuppressions prometheus.Counter
+	commands             *prometheus.CounterVec
+	discordErrors        *prometheus.CounterVec
+	queryDuration        *prometheus.HistogramVec
+}
+
+// New creates the bot's metrics in a registry of their own, along with the Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		messagesScanned: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_scanned_total",
+			Help:      "Messages searched for a time.",
+		}),
+		timeMatches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "time_matches_total",
+			Help:      "Times found in messages, by the format they were written in.",
+		}, []string{"format"}),
+		reactionsAdded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reactions_added_total",
+			Help:      "Reactions added to offer a conversion.",
+		}),
+		conversionsSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "conversions_sent_total",
+			Help:      "Conversion replies posted or updated.",
+		}),
+		cooldownSuppressions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cooldown_suppressions_total",
+			Help:      "Conversions skipped because the message was converted recently.",
+		}),
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "commands_total",
+			Help:      "Slash commands received, by command.",
+		}, []string{"command"}),
+		discordErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "discord_api_errors_total",
+			Help:      "Failed Discord API calls, by call.",
+		}, []string{"call"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency, by query and outcome.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"query", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.messagesScanned,
+		m.timeMatches,
+		m.reactionsAdded,
+		m.conversionsSent,
+		m.cooldownSuppressions,
+		m.commands,
+		m.discordErrors,
+		m.queryDuration,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// MessageScanned counts a message searched for a time
+func (m *Metrics) MessageScanned() { m.messagesScanned.Inc() }
+
+// TimeMatched counts a time found in a message written in format
+func (m *Metrics) TimeMatched(format string) { m.timeMatches.WithLabelValues(format).Inc() }
+
+// ReactionAdded counts a reaction offering a conversion
+func (m *Metrics) ReactionAdded() { m.reactionsAdded.Inc() }
+
+// ConversionSent counts a conversion reply posted or updated
+func (m *Metrics) ConversionSent() { m.conversionsSent.Inc() }
+
+// CooldownSuppressed counts a conversion skipped because of the message's cooldown
+func (m *Metrics) CooldownSuppressed() { m.cooldownSuppressions.Inc() }
+
+// CommandReceived counts a slash command
+func (m *Metrics) CommandReceived(command string) { m.commands.WithLabelValues(command).Inc() }
+
+// DiscordError counts a failed Discord API call
+func (m *Metrics) DiscordError(call string) { m.discordErrors.WithLabelValues(call).Inc() }
+
+// ObserveQuery records the latency of a database query, it is a database.QueryObserver.
+// Queries finding nothing are expected and count as successful.
+func (m *Metrics) ObserveQuery(query string, took time.Duration, err error) {
+	result := "ok"
+	if err != nil && !errors.Is(err, database.ErrNoRows) {
+		result = "error"
+	}
+	m.queryDuration.WithLabelValues(query, result).Observe(took.Seconds())
+}
+
+var _ database.QueryObserver = (*Metrics)(nil).ObserveQuery
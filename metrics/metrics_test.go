@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+)
+
+// scrape returns the metrics served by m
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("reading metrics: %v", err)
+	}
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	m := New()
+	m.MessageScanned()
+	m.MessageScanned()
+	m.TimeMatched("24-hour format")
+	m.CooldownSuppressed()
+	m.DiscordError("MessageReactionAdd")
+	m.ObserveQuery("GetTimezone", time.Millisecond, database.ErrNoRows)
+	m.ObserveQuery("SetTimezone", time.Millisecond, errors.New("disk full"))
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		"timezonebot_messages_scanned_total 2",
+		`timezonebot_time_matches_total{format="24-hour format"} 1`,
+		"timezonebot_cooldown_suppressions_total 1",
+		`timezonebot_discord_api_errors_total{call="MessageReactionAdd"} 1`,
+		`timezonebot_db_query_duration_seconds_count{query="GetTimezone",result="ok"} 1`,
+		`timezonebot_db_query_duration_seconds_count{query="SetTimezone",result="error"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics are missing %q", want)
+		}
+	}
+}
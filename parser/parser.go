@@ -40,6 +40,13 @@ func NewTimeParserWithFormats(formats ...TimeFormat) *TimeParser {
 
 // ParseTimeFromMessage parses number of seconds since midnight from a message
 func (tp *TimeParser) ParseTimeFromMessage(message string) (uint, error) {
+	seconds, _, err := tp.ParseTimeWithFormat(message)
+	return seconds, err
+}
+
+// ParseTimeWithFormat parses number of seconds since midnight from a message,
+// along with the format the time was written in
+func (tp *TimeParser) ParseTimeWithFormat(message string) (uint, TimeFormat, error) {
 	lowerMessage := strings.ToLower(message)
 
 	var allMatches []MatchResult
@@ -72,11 +79,12 @@ func (tp *TimeParser) ParseTimeFromMessage(message string) (uint, error) {
 				best = m
 			}
 		}
-		seconds, err := tp.formats[best.PatternIdx].Handler(best.Matches, lowerMessage)
+		format := tp.formats[best.PatternIdx]
+		seconds, err := format.Handler(best.Matches, lowerMessage)
 		if err == nil {
-			return seconds, nil
+			return seconds, format, nil
 		}
 	}
 
-	return 0, fmt.Errorf("no valid time format found in message: %s", message)
+	return 0, TimeFormat{}, fmt.Errorf("no valid time format found in message: %s", message)
 }
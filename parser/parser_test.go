@@ -145,6 +145,35 @@ func TestNewTimeParserWithFormats(t *testing.T) {
 	}
 }
 
+func TestTimeParser_ParseTimeWithFormat(t *testing.T) {
+	tests := []struct {
+		message string
+		format  string
+	}{
+		{"meet at 6:30 pm", Format12Hour.Name},
+		{"meet at 18:30", Format24Hour.Name},
+		{"meet at 1830", FormatMilitary.Name},
+		{"meet at 6 o'clock", FormatSimpleHour.Name},
+	}
+
+	tp := NewTimeParser()
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			_, format, err := tp.ParseTimeWithFormat(tt.message)
+			if err != nil {
+				t.Fatalf("ParseTimeWithFormat() unexpected error: %v", err)
+			}
+			if format.Name != tt.format {
+				t.Errorf("ParseTimeWithFormat() format = %q, want %q", format.Name, tt.format)
+			}
+		})
+	}
+
+	if _, format, err := tp.ParseTimeWithFormat("no time here"); err == nil || format.Name != "" {
+		t.Errorf("ParseTimeWithFormat() = %q, %v, want no format and an error", format.Name, err)
+	}
+}
+
 func TestParse12HourFormat(t *testing.T) {
 	tests := []struct {
 		name     string
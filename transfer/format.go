@@ -0,0 +1,189 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Format is how records are laid out in an export file
+type Format string
+
+const (
+	// FormatJSONL writes a JSON object per line
+	FormatJSONL Format = "jsonl"
+	// FormatCSV writes a user_id,timezone header followed by a row per record
+	FormatCSV Format = "csv"
+)
+
+var csvHeader = []string{"user_id", "timezone"}
+
+// ParseFormat returns the format called name, or the one matching the extension of path
+// when name is empty
+func ParseFormat(name, path string) (Format, error) {
+	if name == "" {
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			return FormatCSV, nil
+		}
+		return FormatJSONL, nil
+	}
+
+	switch format := Format(strings.ToLower(name)); format {
+	case FormatJSONL, FormatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format: %s", name)
+	}
+}
+
+// Record is the timezone a user saved
+type Record struct {
+	UserID   string `json:"user_id"`
+	Timezone string `json:"timezone"`
+}
+
+// Validate reports an error unless the record has a Discord user ID and a timezone Go knows
+func (r Record) Validate() error {
+	if r.UserID == "" || strings.Trim(r.UserID, "0123456789") != "" {
+		return fmt.Errorf("invalid user ID %q", r.UserID)
+	}
+	// LoadLocation takes "" and "Local" for UTC and the machine's zone, neither is a real choice
+	if r.Timezone == "" || r.Timezone == "Local" {
+		return fmt.Errorf("invalid timezone %q", r.Timezone)
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", r.Timezone)
+	}
+	return nil
+}
+
+// Writer writes records in a Format
+type Writer interface {
+	Write(r Record) error
+	// Flush writes any buffered record, it must be called once done
+	Flush() error
+}
+
+// NewWriter creates a Writer of format writing to w
+func NewWriter(w io.Writer, format Format) Writer {
+	if format == FormatCSV {
+		return &csvWriter{w: csv.NewWriter(w)}
+	}
+	bw := bufio.NewWriter(w)
+	return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(r Record) error { return w.enc.Encode(r) }
+func (w *jsonlWriter) Flush() error         { return w.w.Flush() }
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvWriter) Write(r Record) error {
+	if !w.headerWritten {
+		if err := w.w.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	return w.w.Write([]string{r.UserID, r.Timezone})
+}
+
+func (w *csvWriter) Flush() error {
+	// An empty export still gets its header, so it can be told apart from a truncated file
+	if !w.headerWritten {
+		if err := w.w.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// Reader reads records in a Format
+type Reader interface {
+	// Read returns the next record, or io.EOF once there are none left. Errors mention the
+	// line the record is on.
+	Read() (Record, error)
+}
+
+// NewReader creates a Reader of format reading from r
+func NewReader(r io.Reader, format Format) Reader {
+	if format == FormatCSV {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = len(csvHeader)
+		return &csvReader{r: cr}
+	}
+	scanner := bufio.NewScanner(r)
+	return &jsonlReader{scanner: scanner}
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *jsonlReader) Read() (Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var rec Record
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rec); err != nil {
+			return Record{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return rec, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Record{}, fmt.Errorf("line %d: %w", r.line+1, err)
+	}
+	return Record{}, io.EOF
+}
+
+type csvReader struct {
+	r          *csv.Reader
+	headerRead bool
+}
+
+func (r *csvReader) Read() (Record, error) {
+	if !r.headerRead {
+		header, err := r.r.Read()
+		if err == io.EOF {
+			return Record{}, errors.New("missing user_id,timezone header")
+		}
+		if err != nil {
+			return Record{}, err
+		}
+		if !slices.Equal(header, csvHeader) {
+			return Record{}, fmt.Errorf("line 1: header is %q, want user_id,timezone", strings.Join(header, ","))
+		}
+		r.headerRead = true
+	}
+
+	row, err := r.r.Read()
+	if err != nil {
+		// csv.ParseError already tells the line
+		return Record{}, err
+	}
+	return Record{UserID: row[0], Timezone: row[1]}, nil
+}
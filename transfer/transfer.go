@@ -0,0 +1,149 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/SHA65536/TimezoneBot/database"
+)
+
+const (
+	// exportPageSize is how many timezones are read from the database at once
+	exportPageSize = 1000
+
+	// maxReportedErrors bounds how many invalid records an import lists before giving up
+	maxReportedErrors = 20
+)
+
+// ExportResult tells what an export wrote
+type ExportResult struct {
+	Exported int
+	// Invalid are the stored records that failed validation and were left out
+	Invalid []Record
+}
+
+// Export writes every saved user timezone to w, leaving out the ones that fail validation
+// so the file can be imported as is
+func Export(ctx context.Context, q database.Querier, w Writer) (ExportResult, error) {
+	var res ExportResult
+	after := ""
+	for {
+		page, err := q.ListTimezones(ctx, database.ListTimezonesParams{AfterUserID: after, MaxRows: exportPageSize})
+		if err != nil {
+			return res, fmt.Errorf("error listing timezones: %w", err)
+		}
+
+		for _, row := range page {
+			rec := Record{UserID: row.UserID, Timezone: row.Timezone}
+			if rec.Validate() != nil {
+				res.Invalid = append(res.Invalid, rec)
+				continue
+			}
+			if err := w.Write(rec); err != nil {
+				return res, err
+			}
+			res.Exported++
+		}
+
+		if len(page) < exportPageSize {
+			return res, w.Flush()
+		}
+		after = page[len(page)-1].UserID
+	}
+}
+
+// ImportResult tells what an import changed
+type ImportResult struct {
+	Created   int
+	Updated   int
+	Unchanged int
+}
+
+// Import saves the timezones read from r, batchSize of them per transaction. Every record is
+// validated before anything is saved, so a bad file changes nothing. If saving fails, the
+// batches before the failing one stay saved and are counted in the result. Like /timezone,
+// importing a user's timezone ends their ongoing trip, which would revert it otherwise.
+func Import(ctx context.Context, store database.Store, r Reader, batchSize int) (ImportResult, error) {
+	records, err := readAll(r)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	batchSize = max(batchSize, 1)
+	var res ImportResult
+	for start := 0; start < len(records); start += batchSize {
+		batch := records[start:min(start+batchSize, len(records))]
+
+		var batchRes ImportResult
+		err := store.InTx(ctx, func(q database.Querier) error {
+			batchRes = ImportResult{}
+			for _, rec := range batch {
+				if err := q.DeleteTravel(ctx, rec.UserID); err != nil {
+					return err
+				}
+
+				current, err := q.GetTimezone(ctx, rec.UserID)
+				switch {
+				case errors.Is(err, database.ErrNoRows):
+					batchRes.Created++
+				case err != nil:
+					return err
+				case current == rec.Timezone:
+					// Saving it again would only add a change to the timezone history
+					batchRes.Unchanged++
+					continue
+				default:
+					batchRes.Updated++
+				}
+
+				err = q.SetTimezone(ctx, database.SetTimezoneParams{UserID: rec.UserID, Timezone: rec.Timezone})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return res, fmt.Errorf("error importing records %d to %d: %w", start+1, start+len(batch), err)
+		}
+
+		res.Created += batchRes.Created
+		res.Updated += batchRes.Updated
+		res.Unchanged += batchRes.Unchanged
+	}
+	return res, nil
+}
+
+// readAll reads and validates every record of r, reporting up to maxReportedErrors invalid ones
+func readAll(r Reader) ([]Record, error) {
+	var records []Record
+	var errs []error
+	for n := 1; ; n++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// The rest of the file can't be trusted once it stops parsing
+			errs = append(errs, err)
+			break
+		}
+
+		if err := rec.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("record %d: %w", n, err))
+			if len(errs) == maxReportedErrors {
+				errs = append(errs, errors.New("too many invalid records, stopping"))
+				break
+			}
+			continue
+		}
+		records = append(records, rec)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return records, nil
+}
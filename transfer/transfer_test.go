@@ -0,0 +1,184 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SHA65536/TimezoneBot/database"
+)
+
+// newTestStore creates a migrated SQLite store in a temporary directory
+func newTestStore(t *testing.T) database.Store {
+	t.Helper()
+
+	cfg := database.DatabaseConfig{
+		Driver: database.DriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "test.db"),
+	}
+	if err := database.RunMigrations(cfg); err != nil {
+		t.Fatalf("RunMigrations() unexpected error: %v", err)
+	}
+
+	store, err := database.MakeDatabase(cfg)
+	if err != nil {
+		t.Fatalf("MakeDatabase() unexpected error: %v", err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func setTimezones(t *testing.T, store database.Store, timezones map[string]string) {
+	t.Helper()
+	for userID, timezone := range timezones {
+		if err := store.SetTimezone(context.Background(), database.SetTimezoneParams{UserID: userID, Timezone: timezone}); err != nil {
+			t.Fatalf("SetTimezone() unexpected error: %v", err)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	for _, format := range []Format{FormatJSONL, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			src := newTestStore(t)
+			setTimezones(t, src, map[string]string{
+				"1": "Asia/Tokyo",
+				"2": "Europe/London",
+				"3": "Not/AZone",
+			})
+
+			var buf bytes.Buffer
+			exported, err := Export(ctx, src, NewWriter(&buf, format))
+			if err != nil {
+				t.Fatalf("Export() unexpected error: %v", err)
+			}
+			if exported.Exported != 2 || len(exported.Invalid) != 1 || exported.Invalid[0].UserID != "3" {
+				t.Errorf("Export() = %+v, want 2 exported and user 3 invalid", exported)
+			}
+
+			dst := newTestStore(t)
+			setTimezones(t, dst, map[string]string{"1": "Asia/Tokyo", "2": "America/New_York"})
+			imported, err := Import(ctx, dst, NewReader(&buf, format), 1)
+			if err != nil {
+				t.Fatalf("Import() unexpected error: %v", err)
+			}
+			if want := (ImportResult{Updated: 1, Unchanged: 1}); imported != want {
+				t.Errorf("Import() = %+v, want %+v", imported, want)
+			}
+			if got, err := dst.GetTimezone(ctx, "2"); err != nil || got != "Europe/London" {
+				t.Errorf("GetTimezone() = %q, %v after import, want Europe/London", got, err)
+			}
+		})
+	}
+}
+
+func TestImport_EndsTravel(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	setTimezones(t, store, map[string]string{"1": "Asia/Tokyo", "2": "Asia/Tokyo"})
+	for _, userID := range []string{"1", "2"} {
+		err := store.SetTravel(ctx, database.SetTravelParams{UserID: userID, HomeTimezone: "Europe/London", ExpiresAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("SetTravel() unexpected error: %v", err)
+		}
+	}
+
+	input := `{"user_id":"1","timezone":"America/New_York"}` + "\n" + `{"user_id":"2","timezone":"Asia/Tokyo"}`
+	if _, err := Import(ctx, store, NewReader(strings.NewReader(input), FormatJSONL), 100); err != nil {
+		t.Fatalf("Import() unexpected error: %v", err)
+	}
+
+	// A trip left in place would switch the user back to Europe/London once it ends
+	for _, userID := range []string{"1", "2"} {
+		if _, err := store.GetTravel(ctx, userID); !errors.Is(err, database.ErrNoRows) {
+			t.Errorf("GetTravel(%s) error = %v after import, want ErrNoRows", userID, err)
+		}
+	}
+}
+
+func TestExport_Pages(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	const users = exportPageSize + 1
+	err := store.InTx(ctx, func(q database.Querier) error {
+		for n := range users {
+			if err := q.SetTimezone(ctx, database.SetTimezoneParams{UserID: fmt.Sprint(n + 1), Timezone: "UTC"}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SetTimezone() unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	res, err := Export(ctx, store, NewWriter(&buf, FormatJSONL))
+	if err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+	if res.Exported != users || strings.Count(buf.String(), "\n") != users {
+		t.Errorf("Export() exported %d records in %d lines, want %d", res.Exported, strings.Count(buf.String(), "\n"), users)
+	}
+}
+
+func TestImport_Invalid(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+	}{
+		{name: "bad timezone", format: FormatJSONL, input: `{"user_id":"1","timezone":"Asia/Tokyo"}` + "\n" + `{"user_id":"2","timezone":"Mars/Olympus"}`},
+		{name: "bad user ID", format: FormatJSONL, input: `{"user_id":"abc","timezone":"Asia/Tokyo"}`},
+		{name: "local timezone", format: FormatJSONL, input: `{"user_id":"1","timezone":"Local"}`},
+		{name: "unknown field", format: FormatJSONL, input: `{"user_id":"1","timezone":"UTC","extra":1}`},
+		{name: "not JSON", format: FormatJSONL, input: `user_id,timezone`},
+		{name: "missing header", format: FormatCSV, input: "1,Asia/Tokyo\n"},
+		{name: "extra column", format: FormatCSV, input: "user_id,timezone\n1,Asia/Tokyo,x\n"},
+		{name: "empty CSV", format: FormatCSV, input: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			if _, err := Import(ctx, store, NewReader(strings.NewReader(tt.input), tt.format), 100); err == nil {
+				t.Fatal("Import() = nil error for an invalid file")
+			}
+			// Nothing is saved from a file with an invalid record
+			if stats, err := store.GetStats(ctx); err != nil || stats.Users != 0 {
+				t.Errorf("GetStats() = %+v, %v after a failed import, want no users", stats, err)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name, path string
+		want       Format
+		wantErr    bool
+	}{
+		{path: "backup.csv", want: FormatCSV},
+		{path: "backup.CSV", want: FormatCSV},
+		{path: "backup.jsonl", want: FormatJSONL},
+		{path: "-", want: FormatJSONL},
+		{name: "csv", path: "backup.jsonl", want: FormatCSV},
+		{name: "JSONL", want: FormatJSONL},
+		{name: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.name, tt.path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormat(%q, %q) = %q, %v, want %q", tt.name, tt.path, got, err, tt.want)
+		}
+	}
+}